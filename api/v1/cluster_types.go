@@ -0,0 +1,437 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// ClusterSpec defines the desired state of Cluster
+type ClusterSpec struct {
+	EnableUserWorkload    bool                  `json:"enableUserWorkload,omitempty"`
+	PrometheusOperator    PrometheusOperator    `json:"prometheusOperator,omitempty"`
+	PrometheusK8S         PrometheusK8S         `json:"prometheusK8s,omitempty"`
+	AlertmanagerMain      AlertmanagerMain      `json:"alertmanagerMain,omitempty"`
+	KubeStateMetrics      KubeStateMetrics      `json:"kubeStateMetrics,omitempty"`
+	K8sPrometheusAdapter  K8sPrometheusAdapter  `json:"k8sPrometheusAdapter,omitempty"`
+	MonitoringPlugin      MonitoringPlugin      `json:"monitoringPlugin,omitempty"`
+	NodeExporter          NodeExporter          `json:"nodeExporter,omitempty"`
+	OpenshiftStateMetrics OpenshiftStateMetrics `json:"openshiftStateMetrics,omitempty"`
+	TelemeterClient       TelemeterClient       `json:"telemeterClient,omitempty"`
+	MetricsServer         MetricsServer         `json:"metricsServer,omitempty"`
+	ThanosQuerier         ThanosQuerier         `json:"thanosQuerier,omitempty"`
+	// PrometheusOperatorAdmissionWebhook sizes the webhook that validates
+	// PrometheusRules and AlertmanagerConfigs, including those of user projects.
+	PrometheusOperatorAdmissionWebhook PrometheusOperatorAdmissionWebhook `json:"prometheusOperatorAdmissionWebhook,omitempty"`
+	// UserWorkload restricts what user projects may configure. It is set here
+	// rather than on the User CR because CMO reads it from this ConfigMap.
+	UserWorkload UserWorkload `json:"userWorkload,omitempty"`
+	// SnapshotBeforeChange takes a VolumeSnapshot of each monitoring PVC before
+	// the controller expands it.
+	SnapshotBeforeChange *SnapshotBeforeChange `json:"snapshotBeforeChange,omitempty"`
+	// DefaultsProfile selects the defaults filled in for omitted fields when
+	// the CR is created or updated. The filled in fields are listed in the
+	// monitoring.arthurvardevanyan.com/applied-defaults annotation.
+	DefaultsProfile DefaultsProfile `json:"defaultsProfile,omitempty"`
+	// Sizing expands into the resources, storage and retention of Prometheus,
+	// Alertmanager and Thanos Querier that are not set. The effective values are
+	// listed in status.sizing.
+	Sizing Sizing `json:"sizing,omitempty"`
+}
+
+// DefaultsProfile is a set of defaults for omitted fields. none fills in
+// nothing, ha spreads Prometheus and Alertmanager across nodes and zones and
+// keeps long retention, and minimal keeps short retention. Both ha and minimal
+// set logLevel info.
+// +kubebuilder:validation:Enum=none;ha;minimal
+type DefaultsProfile string
+
+const (
+	DefaultsProfileNone    DefaultsProfile = "none"
+	DefaultsProfileHA      DefaultsProfile = "ha"
+	DefaultsProfileMinimal DefaultsProfile = "minimal"
+)
+
+// Sizing is a preset of component resources, storage and retention. custom
+// leaves them to the spec.
+// +kubebuilder:validation:Enum=small;medium;large;custom
+type Sizing string
+
+const (
+	SizingSmall  Sizing = "small"
+	SizingMedium Sizing = "medium"
+	SizingLarge  Sizing = "large"
+	SizingCustom Sizing = "custom"
+)
+
+// SnapshotBeforeChange configures the VolumeSnapshots taken of monitoring PVCs
+// before the controller changes them.
+type SnapshotBeforeChange struct {
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+	// Retention is the number of snapshots kept per PVC; older ones are pruned.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=3
+	Retention int32 `json:"retention,omitempty"`
+}
+
+// CommonPodSettings are the scheduling, sizing and logging settings shared by
+// the monitoring components CMO accepts a logLevel for. It is embedded inline,
+// so the fields render at the top level of each component's section of the
+// ConfigMap.
+type CommonPodSettings struct {
+	// LogLevel is the verbosity of the component's logs.
+	// +kubebuilder:validation:Enum=error;warn;info;debug
+	LogLevel    string `json:"logLevel,omitempty"`
+	PodSettings `json:",inline"`
+}
+
+// PodSettings are the scheduling and sizing settings of CommonPodSettings,
+// embedded on their own by the components CMO has no logLevel for.
+type PodSettings struct {
+	NodeSelector              map[string]string                 `json:"nodeSelector,omitempty"`
+	Resources                 *corev1.ResourceRequirements      `json:"resources,omitempty"`
+	Tolerations               []corev1.Toleration               `json:"tolerations,omitempty"`
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="!has(self.enabled) || self.enabled || !has(self.enableUserAlertmanagerConfig) || !self.enableUserAlertmanagerConfig",message="enableUserAlertmanagerConfig requires the Alertmanager to be enabled"
+type AlertmanagerMain struct {
+	CommonPodSettings `json:",inline"`
+	// Enabled deploys the platform Alertmanager; CMO enables it if unset.
+	Enabled                      *bool `json:"enabled,omitempty"`
+	EnableUserAlertmanagerConfig bool  `json:"enableUserAlertmanagerConfig,omitempty"`
+	// Secrets lists Secrets in openshift-monitoring to mount into Alertmanager
+	// under /etc/alertmanager/secrets/. Each Secret must exist.
+	// +listType=set
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	// +kubebuilder:validation:items:MaxLength=253
+	Secrets             []string                              `json:"secrets,omitempty"`
+	VolumeClaimTemplate *corev1.PersistentVolumeClaimTemplate `json:"volumeClaimTemplate,omitempty"`
+}
+type MonitoringPlugin struct {
+	PodSettings `json:",inline"`
+}
+type MetricsServer struct {
+	PodSettings `json:",inline"`
+	Audit       *Audit `json:"audit,omitempty"`
+}
+
+// K8sPrometheusAdapter configures prometheus-adapter, which serves the resource
+// metrics API before OpenShift 4.16. From 4.16 on metricsServer replaces it.
+type K8sPrometheusAdapter struct {
+	PodSettings              `json:",inline"`
+	Audit                    *Audit                    `json:"audit,omitempty"`
+	DedicatedServiceMonitors *DedicatedServiceMonitors `json:"dedicatedServiceMonitors,omitempty"`
+}
+
+// Audit configures the audit log of an aggregated API server.
+type Audit struct {
+	// +kubebuilder:validation:Enum=None;Metadata;Request;RequestResponse
+	Profile string `json:"profile,omitempty"`
+}
+
+// DedicatedServiceMonitors makes prometheus-adapter use dedicated
+// ServiceMonitors for the resource metrics of pods, exposing cAdvisor and
+// kubelet metrics with the timestamps they were collected at.
+type DedicatedServiceMonitors struct {
+	Enabled bool `json:"enabled,omitempty"`
+}
+type KubeStateMetrics struct {
+	PodSettings `json:",inline"`
+}
+
+// NodeExporter configures the node-exporter DaemonSet. It runs on every node and
+// tolerates all taints, so CMO does not accept a nodeSelector or tolerations.
+type NodeExporter struct {
+	Collectors NodeExporterCollectors `json:"collectors,omitempty"`
+	// MaxProcs is the GOMAXPROCS of node-exporter; 0 uses the CMO default.
+	// +kubebuilder:validation:Minimum=0
+	MaxProcs int32 `json:"maxProcs,omitempty"`
+	// IgnoredNetworkDevices lists regular expressions of network devices to
+	// exclude from the netdev and netclass collectors. Unset keeps the CMO
+	// default list, an empty list ignores no devices.
+	IgnoredNetworkDevices *[]string                    `json:"ignoredNetworkDevices,omitempty"`
+	Resources             *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// NodeExporterCollectors enables or disables node-exporter collectors. A nil
+// enabled flag keeps the CMO default for that collector.
+type NodeExporterCollectors struct {
+	CPUFreq    NodeExporterCollector         `json:"cpufreq,omitempty"`
+	TCPStat    NodeExporterCollector         `json:"tcpstat,omitempty"`
+	NetDev     NodeExporterCollector         `json:"netdev,omitempty"`
+	NetClass   NodeExporterNetClassCollector `json:"netclass,omitempty"`
+	BuddyInfo  NodeExporterCollector         `json:"buddyinfo,omitempty"`
+	MountStats NodeExporterCollector         `json:"mountstats,omitempty"`
+	Ksmd       NodeExporterCollector         `json:"ksmd,omitempty"`
+	Processes  NodeExporterCollector         `json:"processes,omitempty"`
+	Systemd    NodeExporterSystemdCollector  `json:"systemd,omitempty"`
+}
+type NodeExporterCollector struct {
+	Enabled *bool `json:"enabled,omitempty"`
+}
+type NodeExporterNetClassCollector struct {
+	Enabled    *bool `json:"enabled,omitempty"`
+	UseNetlink *bool `json:"useNetlink,omitempty"`
+}
+type NodeExporterSystemdCollector struct {
+	Enabled *bool `json:"enabled,omitempty"`
+	// Units lists regular expressions of the systemd units to collect.
+	Units []string `json:"units,omitempty"`
+}
+type OpenshiftStateMetrics struct {
+	PodSettings `json:",inline"`
+}
+
+// TLSConfig configures TLS to an additional Alertmanager. Referenced Secrets
+// must be in the namespace of the Prometheus or Thanos Ruler using it.
+// +kubebuilder:validation:XValidation:rule="has(self.cert) == has(self.key)",message="cert and key must be set together"
+type TLSConfig struct {
+	CA                 *corev1.SecretKeySelector `json:"ca,omitempty"`
+	Cert               *corev1.SecretKeySelector `json:"cert,omitempty"`
+	Key                *corev1.SecretKeySelector `json:"key,omitempty"`
+	ServerName         string                    `json:"serverName,omitempty"`
+	InsecureSkipVerify bool                      `json:"insecureSkipVerify,omitempty"`
+}
+
+// AdditionalAlertManagerConfigs is an Alertmanager that alerts are sent to in
+// addition to the ones deployed by CMO.
+type AdditionalAlertManagerConfigs struct {
+	// +kubebuilder:validation:Enum=v1;v2
+	APIVersion  string                    `json:"apiVersion,omitempty"`
+	BearerToken *corev1.SecretKeySelector `json:"bearerToken,omitempty"`
+	PathPrefix  string                    `json:"pathPrefix,omitempty"`
+	// +kubebuilder:validation:Enum=http;https
+	Scheme string `json:"scheme,omitempty"`
+	// StaticConfigs lists the Alertmanagers as host:port.
+	// +kubebuilder:validation:items:Pattern=`^(\[[0-9a-fA-F:.]+\]|[^:\[\]/\s]+):[0-9]{1,5}$`
+	StaticConfigs []string `json:"staticConfigs,omitempty"`
+	// Timeout is the timeout for sending alerts; CMO defaults to 10s.
+	Timeout   Duration   `json:"timeout,omitempty"`
+	TLSConfig *TLSConfig `json:"tlsConfig,omitempty"`
+}
+
+// Duration is a Prometheus duration, e.g. 30s, 15d or 1h30m.
+// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+type Duration string
+
+// ByteSize is a Prometheus byte size, e.g. 512MB or 10GiB.
+// +kubebuilder:validation:Pattern=`^(0|([0-9]*[.])?[0-9]+((K|M|G|T|E|P)i?)?B)$`
+type ByteSize string
+
+type PrometheusK8S struct {
+	CommonPodSettings             `json:",inline"`
+	AdditionalAlertManagerConfigs []AdditionalAlertManagerConfigs `json:"additionalAlertmanagerConfigs,omitempty"`
+	AutoSizing                    *PrometheusAutoSizing           `json:"autoSizing,omitempty"`
+	// +kubebuilder:validation:Enum=full;minimal
+	CollectionProfile string `json:"collectionProfile,omitempty"`
+	// EnforcedBodySizeLimit drops scrapes whose body is larger than the limit.
+	// automatic derives the limit from the cluster's capacity.
+	// +kubebuilder:validation:Pattern=`^(automatic|0|([0-9]*[.])?[0-9]+((K|M|G|T|E|P)i?)?B)$`
+	EnforcedBodySizeLimit string `json:"enforcedBodySizeLimit,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	EnforcedSampleLimit *int64     `json:"enforcedSampleLimit,omitempty"`
+	Exemplars           *Exemplars `json:"exemplars,omitempty"`
+	// ExternalLabels are added to the series and alerts Prometheus sends. Their
+	// values are the only fields that may use {{ name }} template variables.
+	// +kubebuilder:validation:XValidation:rule="!('prometheus' in self) && !('prometheus_replica' in self)",message="prometheus and prometheus_replica are reserved external labels"
+	// +kubebuilder:validation:XValidation:rule="self.all(name, name.matches('^[a-zA-Z_][a-zA-Z0-9_]*$'))",message="external label names must match [a-zA-Z_][a-zA-Z0-9_]*"
+	ExternalLabels    map[string]string `json:"externalLabels,omitempty"`
+	PriorityClassName string            `json:"priorityClassName,omitempty"`
+	// QueryLogFile is a file in /var/log/prometheus, or an absolute path, that
+	// PromQL queries are logged to.
+	// +kubebuilder:validation:XValidation:rule="!self.startsWith('/dev/') || self in ['/dev/stdout', '/dev/stderr']",message="only /dev/stdout and /dev/stderr are allowed under /dev"
+	QueryLogFile  string   `json:"queryLogFile,omitempty"`
+	Retention     Duration `json:"retention,omitempty"`
+	RetentionSize ByteSize `json:"retentionSize,omitempty"`
+	// RetentionSizePercent renders retentionSize as this percentage of the
+	// Prometheus PVC capacity when retentionSize is not set explicitly.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	RetentionSizePercent *int32 `json:"retentionSizePercent,omitempty"`
+	// ScrapeInterval is the default scrape interval, between 5s and 5m.
+	// +kubebuilder:validation:XValidation:rule="!self.matches('[ywd]') && duration(self) >= duration('5s') && duration(self) <= duration('5m')",message="must be between 5s and 5m"
+	ScrapeInterval      Duration                              `json:"scrapeInterval,omitempty"`
+	VolumeClaimTemplate *corev1.PersistentVolumeClaimTemplate `json:"volumeClaimTemplate,omitempty"`
+}
+
+// PrometheusAutoSizing sizes the CPU and memory of Prometheus from the scale of
+// the cluster. Resources set in the spec win over the computed ones, which win
+// over the sizing preset.
+type PrometheusAutoSizing struct {
+	// HeadSeries also sizes the memory from the head series count in the TSDB
+	// status of Prometheus.
+	HeadSeries bool `json:"headSeries,omitempty"`
+	// MinAllowed and MaxAllowed bound the computed requests. The limits are
+	// twice the requests.
+	MinAllowed corev1.ResourceList `json:"minAllowed,omitempty"`
+	MaxAllowed corev1.ResourceList `json:"maxAllowed,omitempty"`
+	// TolerancePercent is how far the computed requests may drift from the
+	// current ones before Prometheus is resized, and so restarted.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:default=10
+	TolerancePercent int32 `json:"tolerancePercent,omitempty"`
+}
+
+// Exemplars configures the exemplar storage of Prometheus.
+type Exemplars struct {
+	// MaxSize is the number of exemplars kept in memory; 0 disables storage.
+	// +kubebuilder:validation:Minimum=0
+	MaxSize *int64 `json:"maxSize,omitempty"`
+}
+type PrometheusOperator struct {
+	CommonPodSettings `json:",inline"`
+}
+type PrometheusOperatorAdmissionWebhook struct {
+	Resources                 *corev1.ResourceRequirements      `json:"resources,omitempty"`
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+}
+type UserWorkload struct {
+	// RulesWithoutLabelEnforcementAllowed lets PrometheusRules in the
+	// namespacesWithoutLabelEnforcement of the User CR evaluate across all
+	// namespaces. CMO defaults it to true; requires OpenShift 4.16 or later.
+	RulesWithoutLabelEnforcementAllowed *bool `json:"rulesWithoutLabelEnforcementAllowed,omitempty"`
+}
+type TelemeterClient struct {
+	PodSettings `json:",inline"`
+	ClusterID   string `json:"clusterID,omitempty"`
+	// +kubebuilder:validation:Pattern=`^https?://.+$`
+	TelemeterServerURL string `json:"telemeterServerURL,omitempty"`
+	// Token is the token Telemeter authenticates with, stored in the CR.
+	//
+	// Deprecated: store the token in a Secret and reference it with
+	// tokenSecret, which wins if both are set.
+	Token string `json:"token,omitempty"`
+	// TokenSecret is the key of a Secret in openshift-monitoring holding the
+	// token Telemeter authenticates with; CMO reads it from the pull secret if
+	// neither is set.
+	TokenSecret *corev1.SecretKeySelector `json:"tokenSecret,omitempty"`
+}
+type ThanosQuerier struct {
+	CommonPodSettings `json:",inline"`
+	// EnableCORS sets CORS headers on the Thanos Querier API responses.
+	EnableCORS bool `json:"enableCORS,omitempty"`
+	// EnableRequestLogging logs every query, e.g. while debugging the query
+	// path during an incident.
+	EnableRequestLogging bool `json:"enableRequestLogging,omitempty"`
+}
+
+// PVCStatus records a component whose PVCs could not be resized.
+type PVCStatus struct {
+	// Component is the name of the StatefulSet owning the PVCs.
+	Component  string   `json:"component"`
+	FailedPVCs []string `json:"failedPVCs,omitempty"`
+	Message    string   `json:"message,omitempty"`
+	// RetryCount is the number of consecutive failed resize attempts.
+	RetryCount int32 `json:"retryCount,omitempty"`
+}
+
+// PVCSnapshot records a VolumeSnapshot taken of a PVC before it was changed.
+type PVCSnapshot struct {
+	Name string `json:"name"`
+	// Component is the name of the StatefulSet owning the PVC.
+	Component  string `json:"component"`
+	PVC        string `json:"pvc"`
+	ReadyToUse bool   `json:"readyToUse,omitempty"`
+}
+
+// ComponentSizing is the effective sizing of a component, from the spec and
+// the sizing preset.
+type ComponentSizing struct {
+	// Component is the section of the spec, e.g. prometheusK8s.
+	Component string                       `json:"component"`
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	Storage   *resource.Quantity           `json:"storage,omitempty"`
+	Retention Duration                     `json:"retention,omitempty"`
+}
+
+// AutoSizingStatus is the scale of the cluster last observed by autoSizing and
+// the resources sized from it.
+type AutoSizingStatus struct {
+	Nodes int64 `json:"nodes"`
+	Pods  int64 `json:"pods"`
+	// HeadSeries is unset unless it was queried successfully.
+	HeadSeries *int64 `json:"headSeries,omitempty"`
+	// Resources only change once the requests drift beyond the tolerance.
+	Resources corev1.ResourceRequirements `json:"resources"`
+	// LastResizeTime is when the resources last changed.
+	LastResizeTime *metav1.Time `json:"lastResizeTime,omitempty"`
+}
+
+// ClusterStatus defines the observed state of Cluster
+type ClusterStatus struct {
+	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// +listType=map
+	// +listMapKey=component
+	PVCStatus []PVCStatus `json:"pvcStatus,omitempty"`
+	// +listType=map
+	// +listMapKey=name
+	Snapshots []PVCSnapshot `json:"snapshots,omitempty"`
+	// FieldOwners lists the fields set by a ClusterFragment rather than by this CR.
+	// +listType=map
+	// +listMapKey=path
+	FieldOwners []FieldOwner `json:"fieldOwners,omitempty"`
+	// Sizing is the effective sizing of the components, set while spec.sizing
+	// is.
+	// +listType=map
+	// +listMapKey=component
+	Sizing []ComponentSizing `json:"sizing,omitempty"`
+	// AutoSizing is set while spec.prometheusK8s.autoSizing is.
+	AutoSizing *AutoSizingStatus `json:"autoSizing,omitempty"`
+	// UnresolvedVariables are the template variables used by the spec that
+	// have no value.
+	// +listType=set
+	UnresolvedVariables []string `json:"unresolvedVariables,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:storageversion
+
+// Cluster is the Schema for the clusters API
+type Cluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterSpec   `json:"spec,omitempty"`
+	Status ClusterStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterList contains a list of Cluster
+type ClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Cluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Cluster{}, &ClusterList{})
+}
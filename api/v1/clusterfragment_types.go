@@ -0,0 +1,81 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterFragmentSpec defines part of the Cluster spec owned by one team
+type ClusterFragmentSpec struct {
+	// Priority decides which fragment sets a field that several fragments set;
+	// the highest wins. The Cluster CR itself has priority 0, so any fragment
+	// overrides it. Fragments of equal priority must not set a field to
+	// different values.
+	// +kubebuilder:validation:Minimum=1
+	Priority int32 `json:"priority"`
+	// Components are the top-level sections of the spec the fragment may set,
+	// e.g. prometheusK8s. A fragment setting any other section is rejected.
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	Components []string `json:"components"`
+	// Config is merged into the Cluster spec.
+	Config ClusterSpec `json:"config,omitempty"`
+}
+
+// FragmentStatus defines the observed state of a fragment
+type FragmentStatus struct {
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// FieldOwner records the fragment that set a field of the rendered spec.
+type FieldOwner struct {
+	// Path is the dotted path of the field, e.g. prometheusK8s.retention.
+	Path     string `json:"path"`
+	Fragment string `json:"fragment"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Priority",type=integer,JSONPath=`.spec.priority`
+//+kubebuilder:printcolumn:name="Merged",type=string,JSONPath=`.status.conditions[?(@.type=="Merged")].status`
+//+kubebuilder:storageversion
+
+// ClusterFragment is the Schema for the clusterfragments API
+type ClusterFragment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterFragmentSpec `json:"spec,omitempty"`
+	Status FragmentStatus      `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterFragmentList contains a list of ClusterFragment
+type ClusterFragmentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterFragment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterFragment{}, &ClusterFragmentList{})
+}
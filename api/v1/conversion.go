@@ -0,0 +1,63 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+)
+
+// v1 is the hub the other API versions convert through.
+
+func (*Cluster) Hub()             {}
+func (*User) Hub()                {}
+func (*ClusterFragment) Hub()     {}
+func (*UserFragment) Hub()        {}
+func (*UserWorkloadRequest) Hub() {}
+
+// ConversionDataAnnotation keeps the fields an object was converted from that
+// the version it was converted to cannot represent, so that converting it back
+// restores them.
+const ConversionDataAnnotation = "monitoring.arthurvardevanyan.com/conversion-data"
+
+// V1beta1Data is what a v1 object keeps of the v1beta1 fields without a v1
+// equivalent.
+// +kubebuilder:object:generate=false
+type V1beta1Data struct {
+	// ServerNames are the TLS server names of the additional Alertmanagers
+	// that set the capitalised ServerName key, by the path of the Alertmanager.
+	ServerNames map[string]V1beta1ServerName `json:"serverNames,omitempty"`
+}
+
+// V1beta1ServerName is the pair of TLS server names of a v1beta1 additional
+// Alertmanager, which v1 merges into serverName.
+// +kubebuilder:object:generate=false
+type V1beta1ServerName struct {
+	ServerName       string `json:"serverName,omitempty"`
+	LegacyServerName string `json:"legacyServerName,omitempty"`
+}
+
+// V1beta1DataOf returns the v1beta1 fields kept in the annotations of an object,
+// which is empty for an object not created through v1beta1.
+func V1beta1DataOf(annotations map[string]string) (V1beta1Data, error) {
+	var data V1beta1Data
+	raw, ok := annotations[ConversionDataAnnotation]
+	if !ok {
+		return data, nil
+	}
+	err := json.Unmarshal([]byte(raw), &data)
+	return data, err
+}
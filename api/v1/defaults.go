@@ -0,0 +1,142 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// AppliedDefaultsAnnotation lists the spec fields the defaulting webhook filled
+// in, comma separated. Fields stay listed after they are changed.
+const AppliedDefaultsAnnotation = "monitoring.arthurvardevanyan.com/applied-defaults"
+
+// profileDefaults are the values a DefaultsProfile fills in.
+// +kubebuilder:object:generate=false
+type profileDefaults struct {
+	logLevel string
+	// retention is the Prometheus retention of the platform, userRetention
+	// that of user workload monitoring.
+	retention     Duration
+	userRetention Duration
+	// spread sets topologySpreadConstraints across nodes and zones.
+	spread bool
+}
+
+var defaultsProfiles = map[DefaultsProfile]profileDefaults{
+	DefaultsProfileHA:      {logLevel: "info", retention: "15d", userRetention: "7d", spread: true},
+	DefaultsProfileMinimal: {logLevel: "info", retention: "24h", userRetention: "24h"},
+}
+
+// defaulter fills in the defaults of a profile and records the paths of the
+// fields it filled in.
+// +kubebuilder:object:generate=false
+type defaulter struct {
+	defaults profileDefaults
+	applied  []string
+}
+
+func (d *defaulter) logLevel(path string, logLevel *string) {
+	if *logLevel == "" && d.defaults.logLevel != "" {
+		*logLevel = d.defaults.logLevel
+		d.applied = append(d.applied, path+".logLevel")
+	}
+}
+
+func (d *defaulter) retention(path string, retention *Duration, value Duration) {
+	if *retention == "" && value != "" {
+		*retention = value
+		d.applied = append(d.applied, path+".retention")
+	}
+}
+
+// spread spreads the pods labelled app.kubernetes.io/name=app across nodes and
+// zones, the same way as the samples.
+func (d *defaulter) spread(path string, constraints *[]corev1.TopologySpreadConstraint, app string) {
+	if len(*constraints) > 0 || !d.defaults.spread {
+		return
+	}
+	for _, key := range []string{corev1.LabelHostname, corev1.LabelTopologyZone} {
+		*constraints = append(*constraints, corev1.TopologySpreadConstraint{
+			MaxSkew:           1,
+			TopologyKey:       key,
+			WhenUnsatisfiable: corev1.DoNotSchedule,
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app.kubernetes.io/name": app},
+			},
+		})
+	}
+	d.applied = append(d.applied, path+".topologySpreadConstraints")
+}
+
+// record adds the applied defaults to the AppliedDefaultsAnnotation of meta.
+func (d *defaulter) record(meta *metav1.ObjectMeta) {
+	if len(d.applied) == 0 {
+		return
+	}
+	applied := d.applied
+	if existing := meta.Annotations[AppliedDefaultsAnnotation]; existing != "" {
+		applied = append(applied, strings.Split(existing, ",")...)
+	}
+	slices.Sort(applied)
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	meta.Annotations[AppliedDefaultsAnnotation] = strings.Join(slices.Compact(applied), ",")
+}
+
+// Default fills in the omitted fields of the spec.defaultsProfile.
+func (r *Cluster) Default() {
+	d := defaulter{defaults: defaultsProfiles[r.Spec.DefaultsProfile]}
+	prometheus := &r.Spec.PrometheusK8S
+	d.logLevel("prometheusK8s", &prometheus.LogLevel)
+	d.retention("prometheusK8s", &prometheus.Retention, d.defaults.retention)
+	d.spread("prometheusK8s", &prometheus.TopologySpreadConstraints, "prometheus")
+	if alertmanager := &r.Spec.AlertmanagerMain; alertmanager.Enabled == nil || *alertmanager.Enabled {
+		d.logLevel("alertmanagerMain", &alertmanager.LogLevel)
+		d.spread("alertmanagerMain", &alertmanager.TopologySpreadConstraints, "alertmanager")
+	}
+	d.record(&r.ObjectMeta)
+}
+
+// Default fills in the omitted fields of the spec.defaultsProfile.
+func (r *User) Default() {
+	d := defaulter{defaults: defaultsProfiles[r.Spec.DefaultsProfile]}
+	prometheus := &r.Spec.Prometheus
+	d.logLevel("prometheus", &prometheus.LogLevel)
+	d.retention("prometheus", &prometheus.Retention, d.defaults.userRetention)
+	d.spread("prometheus", &prometheus.TopologySpreadConstraints, "prometheus")
+	if alertmanager := &r.Spec.Alertmanager; alertmanager.Enabled {
+		d.logLevel("alertmanager", &alertmanager.LogLevel)
+		d.spread("alertmanager", &alertmanager.TopologySpreadConstraints, "alertmanager")
+	}
+	d.record(&r.ObjectMeta)
+}
+
+// defaulterFunc adapts the Default method of a kind to admission.Defaulter.
+// +kubebuilder:object:generate=false
+type defaulterFunc[T runtime.Object] func(T)
+
+func (f defaulterFunc[T]) Default(_ context.Context, obj T) error {
+	f(obj)
+	return nil
+}
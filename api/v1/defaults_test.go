@@ -0,0 +1,102 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterDefault(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster Cluster
+		want    string
+	}{
+		{
+			name:    "no profile",
+			cluster: Cluster{},
+		},
+		{
+			name:    "none",
+			cluster: Cluster{Spec: ClusterSpec{DefaultsProfile: DefaultsProfileNone}},
+		},
+		{
+			name:    "ha",
+			cluster: Cluster{Spec: ClusterSpec{DefaultsProfile: DefaultsProfileHA}},
+			want:    "alertmanagerMain.logLevel,alertmanagerMain.topologySpreadConstraints,prometheusK8s.logLevel,prometheusK8s.retention,prometheusK8s.topologySpreadConstraints",
+		},
+		{
+			name: "set fields are kept",
+			cluster: Cluster{Spec: ClusterSpec{
+				DefaultsProfile:  DefaultsProfileMinimal,
+				PrometheusK8S:    PrometheusK8S{Retention: "3d"},
+				AlertmanagerMain: AlertmanagerMain{CommonPodSettings: CommonPodSettings{LogLevel: "debug"}},
+			}},
+			want: "prometheusK8s.logLevel",
+		},
+		{
+			name: "previously applied defaults stay listed",
+			cluster: Cluster{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{AppliedDefaultsAnnotation: "prometheusK8s.retention"}},
+				Spec: ClusterSpec{
+					DefaultsProfile:  DefaultsProfileMinimal,
+					PrometheusK8S:    PrometheusK8S{Retention: "3d"},
+					AlertmanagerMain: AlertmanagerMain{Enabled: boolPointer(false)},
+				},
+			},
+			want: "prometheusK8s.logLevel,prometheusK8s.retention",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := tt.cluster.DeepCopy()
+			cluster.Default()
+			if got := cluster.Annotations[AppliedDefaultsAnnotation]; got != tt.want {
+				t.Errorf("applied defaults = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserDefault(t *testing.T) {
+	user := &User{Spec: UserSpec{DefaultsProfile: DefaultsProfileHA}}
+	user.Default()
+
+	prometheus := user.Spec.Prometheus
+	if prometheus.LogLevel != "info" || prometheus.Retention != "7d" {
+		t.Errorf("prometheus logLevel, retention = %q, %q, want info, 7d", prometheus.LogLevel, prometheus.Retention)
+	}
+	if len(prometheus.TopologySpreadConstraints) != 2 {
+		t.Fatalf("prometheus topologySpreadConstraints = %v, want hostname and zone", prometheus.TopologySpreadConstraints)
+	}
+	for i, key := range []string{"kubernetes.io/hostname", "topology.kubernetes.io/zone"} {
+		constraint := prometheus.TopologySpreadConstraints[i]
+		if constraint.TopologyKey != key || constraint.LabelSelector.MatchLabels["app.kubernetes.io/name"] != "prometheus" {
+			t.Errorf("prometheus topologySpreadConstraints[%d] = %v, want %s for prometheus", i, constraint, key)
+		}
+	}
+	// The user workload Alertmanager is left alone while it is disabled
+	if user.Spec.Alertmanager.LogLevel != "" {
+		t.Errorf("alertmanager logLevel = %q, want unset", user.Spec.Alertmanager.LogLevel)
+	}
+}
+
+func boolPointer(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// UserSpec defines the desired state of User
+type UserSpec struct {
+	Alertmanager       Alertmanager       `json:"alertmanager,omitempty"`
+	PrometheusOperator PrometheusOperator `json:"prometheusOperator,omitempty"`
+	Prometheus         Prometheus         `json:"prometheus,omitempty"`
+	ThanosRuler        ThanosRuler        `json:"thanosRuler,omitempty"`
+	// NamespacesWithoutLabelEnforcement lists namespaces whose alerts and rules
+	// are not forced to match their own namespace label. Namespaces that do not
+	// exist are reported in status and left out of the ConfigMap.
+	// +listType=set
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:items:MaxLength=63
+	NamespacesWithoutLabelEnforcement []string `json:"namespacesWithoutLabelEnforcement,omitempty"`
+	// SnapshotBeforeChange takes a VolumeSnapshot of each monitoring PVC before
+	// the controller expands it.
+	SnapshotBeforeChange *SnapshotBeforeChange `json:"snapshotBeforeChange,omitempty"`
+	// RequestPolicy decides which UserWorkloadRequests are applied. All
+	// requests are rejected while it is not set.
+	RequestPolicy *UserWorkloadRequestPolicy `json:"requestPolicy,omitempty"`
+	// DefaultsProfile selects the defaults filled in for omitted fields when
+	// the CR is created or updated. The filled in fields are listed in the
+	// monitoring.arthurvardevanyan.com/applied-defaults annotation.
+	DefaultsProfile DefaultsProfile `json:"defaultsProfile,omitempty"`
+	// Sizing expands into the resources, storage and retention of Prometheus,
+	// Alertmanager and Thanos Ruler that are not set. The effective values are
+	// listed in status.sizing.
+	Sizing Sizing `json:"sizing,omitempty"`
+}
+
+// UserWorkloadRequestPolicy limits what tenants may change through a
+// UserWorkloadRequest.
+type UserWorkloadRequestPolicy struct {
+	// AllowedFields are the UserWorkloadRequest spec fields a request may set.
+	// +listType=set
+	AllowedFields []UserWorkloadRequestField `json:"allowedFields,omitempty"`
+	// AllowClusterWideLimits lets requests raise enforcedSampleLimit and
+	// enforcedTargetLimit. CMO cannot scope these limits to a namespace, so a
+	// raise asked for by one tenant applies to every namespace.
+	AllowClusterWideLimits bool `json:"allowClusterWideLimits,omitempty"`
+	// MaxEnforcedSampleLimit is the highest enforcedSampleLimit a request may
+	// ask for. There is no maximum if not set.
+	// +kubebuilder:validation:Minimum=1
+	MaxEnforcedSampleLimit *int64 `json:"maxEnforcedSampleLimit,omitempty"`
+	// MaxEnforcedTargetLimit is the highest enforcedTargetLimit a request may
+	// ask for. There is no maximum if not set.
+	// +kubebuilder:validation:Minimum=1
+	MaxEnforcedTargetLimit *int64 `json:"maxEnforcedTargetLimit,omitempty"`
+}
+
+// UserWorkloadRequestField is a field of the UserWorkloadRequest spec.
+// +kubebuilder:validation:Enum=excludeFromLabelEnforcement;enforcedSampleLimit;enforcedTargetLimit
+type UserWorkloadRequestField string
+
+type Alertmanager struct {
+	CommonPodSettings `json:",inline"`
+	// Enabled deploys the user workload Alertmanager. It conflicts with
+	// alertmanagerMain.enableUserAlertmanagerConfig in the Cluster CR, which
+	// a validation rule cannot read, so the controller only warns about it.
+	Enabled                  bool `json:"enabled,omitempty"`
+	EnableAlertmanagerConfig bool `json:"enableAlertmanagerConfig,omitempty"`
+	// Secrets lists Secrets in openshift-user-workload-monitoring to mount into
+	// Alertmanager under /etc/alertmanager/secrets/. Each Secret must exist.
+	// +listType=set
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	// +kubebuilder:validation:items:MaxLength=253
+	Secrets             []string                              `json:"secrets,omitempty"`
+	VolumeClaimTemplate *corev1.PersistentVolumeClaimTemplate `json:"volumeClaimTemplate,omitempty"`
+}
+type Prometheus struct {
+	CommonPodSettings             `json:",inline"`
+	AdditionalAlertManagerConfigs []AdditionalAlertManagerConfigs `json:"additionalAlertmanagerConfigs,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	EnforcedLabelLimit *int64 `json:"enforcedLabelLimit,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	EnforcedLabelNameLengthLimit *int64 `json:"enforcedLabelNameLengthLimit,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	EnforcedLabelValueLengthLimit *int64 `json:"enforcedLabelValueLengthLimit,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	EnforcedSampleLimit *int64 `json:"enforcedSampleLimit,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	EnforcedTargetLimit *int64 `json:"enforcedTargetLimit,omitempty"`
+	// EvaluationInterval is the default rule evaluation interval, between 5s
+	// and 5m.
+	// +kubebuilder:validation:XValidation:rule="!self.matches('[ywd]') && duration(self) >= duration('5s') && duration(self) <= duration('5m')",message="must be between 5s and 5m"
+	EvaluationInterval Duration `json:"evaluationInterval,omitempty"`
+	// ExternalLabels are added to the series and alerts Prometheus sends. Their
+	// values are the only fields that may use {{ name }} template variables.
+	// +kubebuilder:validation:XValidation:rule="!('prometheus' in self) && !('prometheus_replica' in self)",message="prometheus and prometheus_replica are reserved external labels"
+	// +kubebuilder:validation:XValidation:rule="self.all(name, name.matches('^[a-zA-Z_][a-zA-Z0-9_]*$'))",message="external label names must match [a-zA-Z_][a-zA-Z0-9_]*"
+	ExternalLabels map[string]string `json:"externalLabels,omitempty"`
+	// QueryLogFile is a file in /var/log/prometheus, or an absolute path, that
+	// PromQL queries are logged to.
+	// +kubebuilder:validation:XValidation:rule="!self.startsWith('/dev/') || self in ['/dev/stdout', '/dev/stderr']",message="only /dev/stdout and /dev/stderr are allowed under /dev"
+	QueryLogFile  string            `json:"queryLogFile,omitempty"`
+	RemoteWrite   []RemoteWriteSpec `json:"remoteWrite,omitempty"`
+	Retention     Duration          `json:"retention,omitempty"`
+	RetentionSize ByteSize          `json:"retentionSize,omitempty"`
+	// RetentionSizePercent renders retentionSize as this percentage of the
+	// Prometheus PVC capacity when retentionSize is not set explicitly.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	RetentionSizePercent *int32 `json:"retentionSizePercent,omitempty"`
+	// ScrapeInterval is the default scrape interval, between 5s and 5m.
+	// +kubebuilder:validation:XValidation:rule="!self.matches('[ywd]') && duration(self) >= duration('5s') && duration(self) <= duration('5m')",message="must be between 5s and 5m"
+	ScrapeInterval      Duration                              `json:"scrapeInterval,omitempty"`
+	VolumeClaimTemplate *corev1.PersistentVolumeClaimTemplate `json:"volumeClaimTemplate,omitempty"`
+}
+
+// RemoteWriteSpec configures a remote write endpoint of Prometheus. It is a
+// subset of the prometheus-operator RemoteWriteSpec.
+// +kubebuilder:validation:XValidation:rule="!has(self.authorization) || !has(self.basicAuth)",message="authorization and basicAuth are mutually exclusive"
+type RemoteWriteSpec struct {
+	// +kubebuilder:validation:Pattern=`^https?://.+$`
+	URL                 string             `json:"url"`
+	Name                string             `json:"name,omitempty"`
+	Authorization       *SafeAuthorization `json:"authorization,omitempty"`
+	BasicAuth           *BasicAuth         `json:"basicAuth,omitempty"`
+	Headers             map[string]string  `json:"headers,omitempty"`
+	MetadataConfig      *MetadataConfig    `json:"metadataConfig,omitempty"`
+	ProxyURL            string             `json:"proxyUrl,omitempty"`
+	QueueConfig         *QueueConfig       `json:"queueConfig,omitempty"`
+	RemoteTimeout       Duration           `json:"remoteTimeout,omitempty"`
+	SendExemplars       *bool              `json:"sendExemplars,omitempty"`
+	TLSConfig           *SafeTLSConfig     `json:"tlsConfig,omitempty"`
+	WriteRelabelConfigs []RelabelConfig    `json:"writeRelabelConfigs,omitempty"`
+}
+type SafeAuthorization struct {
+	Type        string                    `json:"type,omitempty"`
+	Credentials *corev1.SecretKeySelector `json:"credentials,omitempty"`
+}
+type BasicAuth struct {
+	Username corev1.SecretKeySelector `json:"username,omitempty"`
+	Password corev1.SecretKeySelector `json:"password,omitempty"`
+}
+type MetadataConfig struct {
+	Send         bool     `json:"send,omitempty"`
+	SendInterval Duration `json:"sendInterval,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="!has(self.minShards) || !has(self.maxShards) || self.minShards <= self.maxShards",message="minShards must not exceed maxShards"
+type QueueConfig struct {
+	// +kubebuilder:validation:Minimum=0
+	Capacity int `json:"capacity,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	MinShards int `json:"minShards,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	MaxShards int `json:"maxShards,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	MaxSamplesPerSend int      `json:"maxSamplesPerSend,omitempty"`
+	BatchSendDeadline Duration `json:"batchSendDeadline,omitempty"`
+	MinBackoff        Duration `json:"minBackoff,omitempty"`
+	MaxBackoff        Duration `json:"maxBackoff,omitempty"`
+	RetryOnRateLimit  bool     `json:"retryOnRateLimit,omitempty"`
+	SampleAgeLimit    Duration `json:"sampleAgeLimit,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="(has(self.cert) && (has(self.cert.secret) || has(self.cert.configMap))) == has(self.keySecret)",message="cert and keySecret must be set together"
+type SafeTLSConfig struct {
+	CA                 SecretOrConfigMap         `json:"ca,omitempty"`
+	Cert               SecretOrConfigMap         `json:"cert,omitempty"`
+	KeySecret          *corev1.SecretKeySelector `json:"keySecret,omitempty"`
+	ServerName         string                    `json:"serverName,omitempty"`
+	InsecureSkipVerify bool                      `json:"insecureSkipVerify,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="!has(self.secret) || !has(self.configMap)",message="secret and configMap are mutually exclusive"
+type SecretOrConfigMap struct {
+	Secret    *corev1.SecretKeySelector    `json:"secret,omitempty"`
+	ConfigMap *corev1.ConfigMapKeySelector `json:"configMap,omitempty"`
+}
+
+// RelabelConfig is a Prometheus relabeling rule applied to remote written
+// samples.
+// +kubebuilder:validation:XValidation:rule="!has(self.action) || self.action != 'hashmod' || has(self.modulus)",message="modulus is required by the hashmod action"
+type RelabelConfig struct {
+	SourceLabels []string `json:"sourceLabels,omitempty"`
+	Separator    *string  `json:"separator,omitempty"`
+	TargetLabel  string   `json:"targetLabel,omitempty"`
+	// Regex is matched against the joined source labels; it must compile.
+	Regex       string  `json:"regex,omitempty"`
+	Modulus     int64   `json:"modulus,omitempty"`
+	Replacement *string `json:"replacement,omitempty"`
+	// +kubebuilder:validation:Enum=replace;keep;drop;hashmod;labelmap;labeldrop;labelkeep;lowercase;uppercase;keepequal;dropequal
+	Action string `json:"action,omitempty"`
+}
+type ThanosRuler struct {
+	CommonPodSettings `json:",inline"`
+	// AdditionalAlertManagerConfigs are Alertmanagers Thanos Ruler sends alerts
+	// to in addition to the platform and user workload Alertmanagers. Referenced
+	// Secrets must be in openshift-user-workload-monitoring.
+	AdditionalAlertManagerConfigs []AdditionalAlertManagerConfigs `json:"additionalAlertmanagerConfigs,omitempty"`
+	// EvaluationInterval is how often rules are evaluated; CMO defaults to 15s.
+	// +kubebuilder:validation:XValidation:rule="self.matches('[1-9]')",message="must be a positive duration"
+	EvaluationInterval Duration `json:"evaluationInterval,omitempty"`
+	// Retention is how long Thanos Ruler keeps the series it evaluated;
+	// CMO defaults to 24h.
+	Retention           Duration                              `json:"retention,omitempty"`
+	VolumeClaimTemplate *corev1.PersistentVolumeClaimTemplate `json:"volumeClaimTemplate,omitempty"`
+}
+
+// UserStatus defines the observed state of User
+type UserStatus struct {
+	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// +listType=map
+	// +listMapKey=component
+	PVCStatus []PVCStatus `json:"pvcStatus,omitempty"`
+	// +listType=map
+	// +listMapKey=name
+	Snapshots []PVCSnapshot `json:"snapshots,omitempty"`
+	// MissingNamespaces are the namespacesWithoutLabelEnforcement that do not
+	// exist.
+	// +listType=set
+	MissingNamespaces []string `json:"missingNamespaces,omitempty"`
+	// FieldOwners lists the fields set by a UserFragment rather than by this CR.
+	// +listType=map
+	// +listMapKey=path
+	FieldOwners []FieldOwner `json:"fieldOwners,omitempty"`
+	// Sizing is the effective sizing of the components, set while spec.sizing
+	// is.
+	// +listType=map
+	// +listMapKey=component
+	Sizing []ComponentSizing `json:"sizing,omitempty"`
+	// UnresolvedVariables are the template variables used by the spec that
+	// have no value.
+	// +listType=set
+	UnresolvedVariables []string `json:"unresolvedVariables,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:storageversion
+
+// User is the Schema for the users API
+type User struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UserSpec   `json:"spec,omitempty"`
+	Status UserStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// UserList contains a list of User
+type UserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []User `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&User{}, &UserList{})
+}
@@ -0,0 +1,31 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// SetupWebhookWithManager registers the webhooks of the kind with the manager,
+// which serve the conversion from v1beta1 and the defaulting.
+func (r *User) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, r).
+		WithDefaulter(defaulterFunc[*User]((*User).Default)).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-monitoring-arthurvardevanyan-com-v1-user,mutating=true,failurePolicy=fail,sideEffects=None,groups=monitoring.arthurvardevanyan.com,resources=users,verbs=create;update,versions=v1,name=muser.kb.io,admissionReviewVersions=v1
@@ -0,0 +1,349 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+// v1beta1 is converted to and from the v1 hub. Apart from the fields listed in
+// V1beta1Data and v1Data, the versions have the same json field names, so the
+// specs and statuses are converted through their JSON encoding.
+
+// v1Data is what a v1beta1 object keeps of the v1 fields without a v1beta1
+// equivalent.
+// +kubebuilder:object:generate=false
+type v1Data struct {
+	TelemeterTokenSecret *corev1.SecretKeySelector `json:"telemeterTokenSecret,omitempty"`
+}
+
+// ConvertTo converts this Cluster to the hub version.
+func (src *Cluster) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*monitoringv1.Cluster)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	var kept v1Data
+	if err := takeConversionData(&dst.ObjectMeta, &kept); err != nil {
+		return err
+	}
+	var data monitoringv1.V1beta1Data
+	dst.Spec = monitoringv1.ClusterSpec{}
+	if err := convertClusterSpecTo(&src.Spec, &dst.Spec, &data, kept); err != nil {
+		return err
+	}
+	dst.Status = monitoringv1.ClusterStatus{}
+	if err := convertJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+	return putConversionData(&dst.ObjectMeta, data)
+}
+
+// ConvertFrom converts the hub version to this Cluster.
+func (dst *Cluster) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*monitoringv1.Cluster)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	var data monitoringv1.V1beta1Data
+	if err := takeConversionData(&dst.ObjectMeta, &data); err != nil {
+		return err
+	}
+	var kept v1Data
+	dst.Spec = ClusterSpec{}
+	if err := convertClusterSpecFrom(&src.Spec, &dst.Spec, data, &kept); err != nil {
+		return err
+	}
+	dst.Status = ClusterStatus{}
+	if err := convertJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+	return putConversionData(&dst.ObjectMeta, kept)
+}
+
+// ConvertTo converts this User to the hub version.
+func (src *User) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*monitoringv1.User)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if err := takeConversionData(&dst.ObjectMeta, &v1Data{}); err != nil {
+		return err
+	}
+	var data monitoringv1.V1beta1Data
+	dst.Spec = monitoringv1.UserSpec{}
+	if err := convertUserSpecTo(&src.Spec, &dst.Spec, &data); err != nil {
+		return err
+	}
+	dst.Status = monitoringv1.UserStatus{}
+	if err := convertJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+	return putConversionData(&dst.ObjectMeta, data)
+}
+
+// ConvertFrom converts the hub version to this User.
+func (dst *User) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*monitoringv1.User)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	var data monitoringv1.V1beta1Data
+	if err := takeConversionData(&dst.ObjectMeta, &data); err != nil {
+		return err
+	}
+	dst.Spec = UserSpec{}
+	if err := convertUserSpecFrom(&src.Spec, &dst.Spec, data); err != nil {
+		return err
+	}
+	dst.Status = UserStatus{}
+	return convertJSON(&src.Status, &dst.Status)
+}
+
+// ConvertTo converts this ClusterFragment to the hub version.
+func (src *ClusterFragment) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*monitoringv1.ClusterFragment)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	var kept v1Data
+	if err := takeConversionData(&dst.ObjectMeta, &kept); err != nil {
+		return err
+	}
+	var data monitoringv1.V1beta1Data
+	dst.Spec = monitoringv1.ClusterFragmentSpec{
+		Priority:   src.Spec.Priority,
+		Components: slices.Clone(src.Spec.Components),
+	}
+	if err := convertClusterSpecTo(&src.Spec.Config, &dst.Spec.Config, &data, kept); err != nil {
+		return err
+	}
+	dst.Status = monitoringv1.FragmentStatus{}
+	if err := convertJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+	return putConversionData(&dst.ObjectMeta, data)
+}
+
+// ConvertFrom converts the hub version to this ClusterFragment.
+func (dst *ClusterFragment) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*monitoringv1.ClusterFragment)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	var data monitoringv1.V1beta1Data
+	if err := takeConversionData(&dst.ObjectMeta, &data); err != nil {
+		return err
+	}
+	var kept v1Data
+	dst.Spec = ClusterFragmentSpec{
+		Priority:   src.Spec.Priority,
+		Components: slices.Clone(src.Spec.Components),
+	}
+	if err := convertClusterSpecFrom(&src.Spec.Config, &dst.Spec.Config, data, &kept); err != nil {
+		return err
+	}
+	dst.Status = FragmentStatus{}
+	if err := convertJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+	return putConversionData(&dst.ObjectMeta, kept)
+}
+
+// ConvertTo converts this UserFragment to the hub version.
+func (src *UserFragment) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*monitoringv1.UserFragment)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if err := takeConversionData(&dst.ObjectMeta, &v1Data{}); err != nil {
+		return err
+	}
+	var data monitoringv1.V1beta1Data
+	dst.Spec = monitoringv1.UserFragmentSpec{
+		Priority:   src.Spec.Priority,
+		Components: slices.Clone(src.Spec.Components),
+	}
+	if err := convertUserSpecTo(&src.Spec.Config, &dst.Spec.Config, &data); err != nil {
+		return err
+	}
+	dst.Status = monitoringv1.FragmentStatus{}
+	if err := convertJSON(&src.Status, &dst.Status); err != nil {
+		return err
+	}
+	return putConversionData(&dst.ObjectMeta, data)
+}
+
+// ConvertFrom converts the hub version to this UserFragment.
+func (dst *UserFragment) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*monitoringv1.UserFragment)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	var data monitoringv1.V1beta1Data
+	if err := takeConversionData(&dst.ObjectMeta, &data); err != nil {
+		return err
+	}
+	dst.Spec = UserFragmentSpec{
+		Priority:   src.Spec.Priority,
+		Components: slices.Clone(src.Spec.Components),
+	}
+	if err := convertUserSpecFrom(&src.Spec.Config, &dst.Spec.Config, data); err != nil {
+		return err
+	}
+	dst.Status = FragmentStatus{}
+	return convertJSON(&src.Status, &dst.Status)
+}
+
+// ConvertTo converts this UserWorkloadRequest to the hub version.
+func (src *UserWorkloadRequest) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*monitoringv1.UserWorkloadRequest)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec = monitoringv1.UserWorkloadRequestSpec{}
+	if err := convertJSON(&src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	dst.Status = monitoringv1.UserWorkloadRequestStatus{}
+	return convertJSON(&src.Status, &dst.Status)
+}
+
+// ConvertFrom converts the hub version to this UserWorkloadRequest.
+func (dst *UserWorkloadRequest) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*monitoringv1.UserWorkloadRequest)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec = UserWorkloadRequestSpec{}
+	if err := convertJSON(&src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	dst.Status = UserWorkloadRequestStatus{}
+	return convertJSON(&src.Status, &dst.Status)
+}
+
+// convertClusterSpecTo moves the capitalised ServerName keys into data, and
+// restores the tokenSecret kept from v1.
+func convertClusterSpecTo(src *ClusterSpec, dst *monitoringv1.ClusterSpec, data *monitoringv1.V1beta1Data, kept v1Data) error {
+	spec := src.DeepCopy()
+	takeServerNames("prometheusK8s.additionalAlertmanagerConfigs", spec.PrometheusK8S.AdditionalAlertManagerConfigs, data)
+	if err := convertJSON(spec, dst); err != nil {
+		return err
+	}
+	dst.TelemeterClient.TokenSecret = kept.TelemeterTokenSecret
+	return nil
+}
+
+// convertClusterSpecFrom is the reverse of convertClusterSpecTo.
+func convertClusterSpecFrom(src *monitoringv1.ClusterSpec, dst *ClusterSpec, data monitoringv1.V1beta1Data, kept *v1Data) error {
+	spec := src.DeepCopy()
+	kept.TelemeterTokenSecret = spec.TelemeterClient.TokenSecret
+	spec.TelemeterClient.TokenSecret = nil
+	if err := convertJSON(spec, dst); err != nil {
+		return err
+	}
+	restoreServerNames("prometheusK8s.additionalAlertmanagerConfigs", dst.PrometheusK8S.AdditionalAlertManagerConfigs, data)
+	return nil
+}
+
+// convertUserSpecTo moves the capitalised ServerName keys into data.
+func convertUserSpecTo(src *UserSpec, dst *monitoringv1.UserSpec, data *monitoringv1.V1beta1Data) error {
+	spec := src.DeepCopy()
+	takeServerNames("prometheus.additionalAlertmanagerConfigs", spec.Prometheus.AdditionalAlertManagerConfigs, data)
+	takeServerNames("thanosRuler.additionalAlertmanagerConfigs", spec.ThanosRuler.AdditionalAlertManagerConfigs, data)
+	return convertJSON(spec, dst)
+}
+
+// convertUserSpecFrom is the reverse of convertUserSpecTo.
+func convertUserSpecFrom(src *monitoringv1.UserSpec, dst *UserSpec, data monitoringv1.V1beta1Data) error {
+	if err := convertJSON(src, dst); err != nil {
+		return err
+	}
+	restoreServerNames("prometheus.additionalAlertmanagerConfigs", dst.Prometheus.AdditionalAlertManagerConfigs, data)
+	restoreServerNames("thanosRuler.additionalAlertmanagerConfigs", dst.ThanosRuler.AdditionalAlertManagerConfigs, data)
+	return nil
+}
+
+// takeServerNames renders the deprecated ServerName as serverName where that
+// is not set, as the controller did for v1beta1, and records both in data.
+func takeServerNames(path string, configs []AdditionalAlertManagerConfigs, data *monitoringv1.V1beta1Data) {
+	for i := range configs {
+		tlsConfig := configs[i].TLSConfig
+		if tlsConfig == nil || tlsConfig.LegacyServerName == "" {
+			continue
+		}
+		if data.ServerNames == nil {
+			data.ServerNames = make(map[string]monitoringv1.V1beta1ServerName)
+		}
+		data.ServerNames[fmt.Sprintf("%s[%d]", path, i)] = monitoringv1.V1beta1ServerName{
+			ServerName:       tlsConfig.ServerName,
+			LegacyServerName: tlsConfig.LegacyServerName,
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = tlsConfig.LegacyServerName
+		}
+		tlsConfig.LegacyServerName = ""
+	}
+}
+
+// restoreServerNames is the reverse of takeServerNames. A serverName changed
+// since the conversion to v1 is kept as is.
+func restoreServerNames(path string, configs []AdditionalAlertManagerConfigs, data monitoringv1.V1beta1Data) {
+	for i := range configs {
+		names, ok := data.ServerNames[fmt.Sprintf("%s[%d]", path, i)]
+		tlsConfig := configs[i].TLSConfig
+		if !ok || tlsConfig == nil {
+			continue
+		}
+		migrated := names.ServerName
+		if migrated == "" {
+			migrated = names.LegacyServerName
+		}
+		if tlsConfig.ServerName == migrated {
+			tlsConfig.ServerName = names.ServerName
+			tlsConfig.LegacyServerName = names.LegacyServerName
+		}
+	}
+}
+
+// convertJSON converts src into dst, which must be zero, through the JSON
+// encoding of src.
+func convertJSON(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// takeConversionData moves the conversion data kept in meta into data.
+func takeConversionData(meta *metav1.ObjectMeta, data interface{}) error {
+	raw, ok := meta.Annotations[monitoringv1.ConversionDataAnnotation]
+	if !ok {
+		return nil
+	}
+	delete(meta.Annotations, monitoringv1.ConversionDataAnnotation)
+	if err := json.Unmarshal([]byte(raw), data); err != nil {
+		return fmt.Errorf("invalid %s annotation: %w", monitoringv1.ConversionDataAnnotation, err)
+	}
+	return nil
+}
+
+// putConversionData keeps data in meta, unless it is empty.
+func putConversionData(meta *metav1.ObjectMeta, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if string(raw) == "{}" {
+		return nil
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	meta.Annotations[monitoringv1.ConversionDataAnnotation] = string(raw)
+	return nil
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+	"sigs.k8s.io/randfill"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+// fillFuncs keep the filled objects to what the API server could store.
+var fillFuncs = []interface{}{
+	// The conversion webhook sets the apiVersion and kind of the result
+	func(typeMeta *metav1.TypeMeta, c randfill.Continue) {},
+	func(meta *metav1.ObjectMeta, c randfill.Continue) {
+		c.FillNoCustom(meta)
+		// Managed fields hold raw JSON, which random bytes are not
+		meta.ManagedFields = nil
+	},
+	// A null list reads back as unset, e.g. nodeExporter.ignoredNetworkDevices
+	func(list **[]string, c randfill.Continue) {
+		*list = nil
+		if c.Bool() {
+			items := []string{}
+			c.Fill(&items)
+			if items == nil {
+				items = []string{}
+			}
+			*list = &items
+		}
+	},
+	func(q *resource.Quantity, c randfill.Continue) {
+		*q = *resource.NewQuantity(c.Int63n(1<<40), resource.BinarySI)
+	},
+}
+
+func TestConversionRoundTrip(t *testing.T) {
+	tests := []struct {
+		spoke func() conversion.Convertible
+		hub   func() conversion.Hub
+	}{
+		{func() conversion.Convertible { return &Cluster{} }, func() conversion.Hub { return &monitoringv1.Cluster{} }},
+		{func() conversion.Convertible { return &User{} }, func() conversion.Hub { return &monitoringv1.User{} }},
+		{func() conversion.Convertible { return &ClusterFragment{} }, func() conversion.Hub { return &monitoringv1.ClusterFragment{} }},
+		{func() conversion.Convertible { return &UserFragment{} }, func() conversion.Hub { return &monitoringv1.UserFragment{} }},
+		{func() conversion.Convertible { return &UserWorkloadRequest{} }, func() conversion.Hub { return &monitoringv1.UserWorkloadRequest{} }},
+	}
+	filler := randfill.NewWithSeed(1).NilChance(0.3).NumElements(0, 2).Funcs(fillFuncs...)
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%T", tt.spoke()), func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				// v1beta1 -> v1 -> v1beta1
+				spoke := tt.spoke()
+				filler.Fill(spoke)
+				hub := tt.hub()
+				if err := spoke.ConvertTo(hub); err != nil {
+					t.Fatalf("ConvertTo: %v", err)
+				}
+				back := tt.spoke()
+				if err := back.ConvertFrom(hub); err != nil {
+					t.Fatalf("ConvertFrom: %v", err)
+				}
+				if !equality.Semantic.DeepEqual(spoke, back) {
+					t.Fatalf("v1beta1 round trip changed the object:\n%s", diff.Diff(spoke, back))
+				}
+
+				// v1 -> v1beta1 -> v1
+				hub = tt.hub()
+				filler.Fill(hub)
+				spoke = tt.spoke()
+				if err := spoke.ConvertFrom(hub); err != nil {
+					t.Fatalf("ConvertFrom: %v", err)
+				}
+				hubBack := tt.hub()
+				if err := spoke.ConvertTo(hubBack); err != nil {
+					t.Fatalf("ConvertTo: %v", err)
+				}
+				if !equality.Semantic.DeepEqual(hub, hubBack) {
+					t.Fatalf("v1 round trip changed the object:\n%s", diff.Diff(hub, hubBack))
+				}
+			}
+		})
+	}
+}
+
+func TestConvertLegacyFields(t *testing.T) {
+	cluster := &Cluster{
+		Spec: ClusterSpec{
+			PrometheusK8S: PrometheusK8S{
+				AdditionalAlertManagerConfigs: []AdditionalAlertManagerConfigs{
+					{TLSConfig: &TLSConfig{LegacyServerName: "alertmanager.example.com"}},
+					{TLSConfig: &TLSConfig{ServerName: "kept.example.com", LegacyServerName: "ignored.example.com"}},
+				},
+			},
+		},
+	}
+	hub := &monitoringv1.Cluster{}
+	if err := cluster.ConvertTo(hub); err != nil {
+		t.Fatal(err)
+	}
+
+	// The controller rendered ServerName as serverName unless that was set
+	configs := hub.Spec.PrometheusK8S.AdditionalAlertManagerConfigs
+	if configs[0].TLSConfig.ServerName != "alertmanager.example.com" || configs[1].TLSConfig.ServerName != "kept.example.com" {
+		t.Errorf("serverNames = %s, %s; want the migrated names", configs[0].TLSConfig.ServerName, configs[1].TLSConfig.ServerName)
+	}
+
+	// A serverName changed in v1 wins over the one kept from v1beta1
+	configs[0].TLSConfig.ServerName = "changed.example.com"
+	hub.Spec.TelemeterClient.TokenSecret = &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "telemeter"},
+		Key:                  "token",
+	}
+	back := &Cluster{}
+	if err := back.ConvertFrom(hub); err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig := back.Spec.PrometheusK8S.AdditionalAlertManagerConfigs[0].TLSConfig; tlsConfig.ServerName != "changed.example.com" || tlsConfig.LegacyServerName != "" {
+		t.Errorf("tlsConfig = %+v, want the changed serverName only", tlsConfig)
+	}
+	if _, ok := back.Annotations[monitoringv1.ConversionDataAnnotation]; !ok {
+		t.Error("tokenSecret not kept in the v1beta1 object")
+	}
+}
+
+// storedCluster is a v1beta1 Cluster as stored before v1 was added.
+const storedCluster = `{
+	"apiVersion": "monitoring.arthurvardevanyan.com/v1beta1",
+	"kind": "Cluster",
+	"metadata": {"name": "cluster-monitoring-config", "resourceVersion": "4711"},
+	"spec": {
+		"prometheusK8s": {"retention": "15d"},
+		"telemeterClient": {
+			"clusterID": "0b3e7f5c",
+			"nodeSelector": {"node-role.kubernetes.io/infra": ""},
+			"telemeterServerURL": "https://infogw.api.openshift.com",
+			"token": "secret-token"
+		}
+	}
+}`
+
+func TestConvertStoredTelemeterToken(t *testing.T) {
+	cluster := &Cluster{}
+	if err := json.Unmarshal([]byte(storedCluster), cluster); err != nil {
+		t.Fatal(err)
+	}
+	hub := &monitoringv1.Cluster{}
+	if err := cluster.ConvertTo(hub); err != nil {
+		t.Fatalf("ConvertTo: %v", err)
+	}
+	if telemeter := hub.Spec.TelemeterClient; telemeter.Token != "secret-token" || telemeter.ClusterID != "0b3e7f5c" {
+		t.Errorf("telemeterClient = %+v, want the stored token and clusterID", telemeter)
+	}
+	if data, ok := hub.Annotations[monitoringv1.ConversionDataAnnotation]; ok {
+		t.Errorf("conversion data = %s, want the token kept in the spec only", data)
+	}
+
+	back := &Cluster{}
+	if err := back.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom: %v", err)
+	}
+	// The conversion webhook sets the apiVersion and kind of the result
+	back.TypeMeta = cluster.TypeMeta
+	if !equality.Semantic.DeepEqual(cluster, back) {
+		t.Errorf("round trip changed the stored object:\n%s", diff.Diff(cluster, back))
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UserFragmentSpec defines part of the User spec owned by one team
+type UserFragmentSpec struct {
+	// Priority decides which fragment sets a field that several fragments set;
+	// the highest wins. The User CR itself has priority 0, so any fragment
+	// overrides it. Fragments of equal priority must not set a field to
+	// different values.
+	// +kubebuilder:validation:Minimum=1
+	Priority int32 `json:"priority"`
+	// Components are the top-level sections of the spec the fragment may set,
+	// e.g. prometheus. A fragment setting any other section is rejected.
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	Components []string `json:"components"`
+	// Config is merged into the User spec.
+	Config UserSpec `json:"config,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Priority",type=integer,JSONPath=`.spec.priority`
+//+kubebuilder:printcolumn:name="Merged",type=string,JSONPath=`.status.conditions[?(@.type=="Merged")].status`
+
+// UserFragment is the Schema for the userfragments API
+type UserFragment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UserFragmentSpec `json:"spec,omitempty"`
+	Status FragmentStatus   `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// UserFragmentList contains a list of UserFragment
+type UserFragmentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []UserFragment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&UserFragment{}, &UserFragmentList{})
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UserWorkloadRequestSpec defines the user workload monitoring settings a
+// tenant asks for
+type UserWorkloadRequestSpec struct {
+	// ExcludeFromLabelEnforcement adds the request's namespace to the User
+	// namespacesWithoutLabelEnforcement.
+	ExcludeFromLabelEnforcement bool `json:"excludeFromLabelEnforcement,omitempty"`
+	// EnforcedSampleLimit raises the User prometheus.enforcedSampleLimit to at
+	// least this value. It cannot lower it, nor set a limit where there is none.
+	// +kubebuilder:validation:Minimum=1
+	EnforcedSampleLimit *int64 `json:"enforcedSampleLimit,omitempty"`
+	// EnforcedTargetLimit raises the User prometheus.enforcedTargetLimit like
+	// EnforcedSampleLimit.
+	// +kubebuilder:validation:Minimum=1
+	EnforcedTargetLimit *int64 `json:"enforcedTargetLimit,omitempty"`
+}
+
+// UserWorkloadRequestStatus defines the observed state of UserWorkloadRequest
+type UserWorkloadRequestStatus struct {
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Accepted",type=string,JSONPath=`.status.conditions[?(@.type=="Accepted")].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Accepted")].reason`
+
+// UserWorkloadRequest is the Schema for the userworkloadrequests API
+type UserWorkloadRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UserWorkloadRequestSpec   `json:"spec,omitempty"`
+	Status UserWorkloadRequestStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// UserWorkloadRequestList contains a list of UserWorkloadRequest
+type UserWorkloadRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []UserWorkloadRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&UserWorkloadRequest{}, &UserWorkloadRequestList{})
+}
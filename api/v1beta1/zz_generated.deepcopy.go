@@ -22,19 +22,29 @@ package v1beta1
 
 import (
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AdditionalAlertManagerConfigs) DeepCopyInto(out *AdditionalAlertManagerConfigs) {
 	*out = *in
-	out.BearerToken = in.BearerToken
+	if in.BearerToken != nil {
+		in, out := &in.BearerToken, &out.BearerToken
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.StaticConfigs != nil {
 		in, out := &in.StaticConfigs, &out.StaticConfigs
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	out.TLSConfig = in.TLSConfig
+	if in.TLSConfig != nil {
+		in, out := &in.TLSConfig, &out.TLSConfig
+		*out = new(TLSConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdditionalAlertManagerConfigs.
@@ -50,26 +60,11 @@ func (in *AdditionalAlertManagerConfigs) DeepCopy() *AdditionalAlertManagerConfi
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Alertmanager) DeepCopyInto(out *Alertmanager) {
 	*out = *in
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.TopologySpreadConstraints != nil {
-		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
-		*out = make([]v1.TopologySpreadConstraint, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	in.CommonPodSettings.DeepCopyInto(&out.CommonPodSettings)
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 	if in.VolumeClaimTemplate != nil {
 		in, out := &in.VolumeClaimTemplate, &out.VolumeClaimTemplate
@@ -91,31 +86,16 @@ func (in *Alertmanager) DeepCopy() *Alertmanager {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AlertmanagerMain) DeepCopyInto(out *AlertmanagerMain) {
 	*out = *in
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Resources != nil {
-		in, out := &in.Resources, &out.Resources
-		*out = new(v1.ResourceRequirements)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.TopologySpreadConstraints != nil {
-		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
-		*out = make([]v1.TopologySpreadConstraint, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	in.CommonPodSettings.DeepCopyInto(&out.CommonPodSettings)
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 	if in.VolumeClaimTemplate != nil {
 		in, out := &in.VolumeClaimTemplate, &out.VolumeClaimTemplate
@@ -135,31 +115,59 @@ func (in *AlertmanagerMain) DeepCopy() *AlertmanagerMain {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *BearerToken) DeepCopyInto(out *BearerToken) {
+func (in *Audit) DeepCopyInto(out *Audit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Audit.
+func (in *Audit) DeepCopy() *Audit {
+	if in == nil {
+		return nil
+	}
+	out := new(Audit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoSizingStatus) DeepCopyInto(out *AutoSizingStatus) {
 	*out = *in
+	if in.HeadSeries != nil {
+		in, out := &in.HeadSeries, &out.HeadSeries
+		*out = new(int64)
+		**out = **in
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.LastResizeTime != nil {
+		in, out := &in.LastResizeTime, &out.LastResizeTime
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BearerToken.
-func (in *BearerToken) DeepCopy() *BearerToken {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoSizingStatus.
+func (in *AutoSizingStatus) DeepCopy() *AutoSizingStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(BearerToken)
+	out := new(AutoSizingStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Ca) DeepCopyInto(out *Ca) {
+func (in *BasicAuth) DeepCopyInto(out *BasicAuth) {
 	*out = *in
+	in.Username.DeepCopyInto(&out.Username)
+	in.Password.DeepCopyInto(&out.Password)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ca.
-func (in *Ca) DeepCopy() *Ca {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BasicAuth.
+func (in *BasicAuth) DeepCopy() *BasicAuth {
 	if in == nil {
 		return nil
 	}
-	out := new(Ca)
+	out := new(BasicAuth)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -170,7 +178,7 @@ func (in *Cluster) DeepCopyInto(out *Cluster) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Cluster.
@@ -191,6 +199,86 @@ func (in *Cluster) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterFragment) DeepCopyInto(out *ClusterFragment) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFragment.
+func (in *ClusterFragment) DeepCopy() *ClusterFragment {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterFragment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterFragment) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterFragmentList) DeepCopyInto(out *ClusterFragmentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterFragment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFragmentList.
+func (in *ClusterFragmentList) DeepCopy() *ClusterFragmentList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterFragmentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterFragmentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterFragmentSpec) DeepCopyInto(out *ClusterFragmentSpec) {
+	*out = *in
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Config.DeepCopyInto(&out.Config)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFragmentSpec.
+func (in *ClusterFragmentSpec) DeepCopy() *ClusterFragmentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterFragmentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterList) DeepCopyInto(out *ClusterList) {
 	*out = *in
@@ -230,11 +318,20 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 	in.PrometheusK8S.DeepCopyInto(&out.PrometheusK8S)
 	in.AlertmanagerMain.DeepCopyInto(&out.AlertmanagerMain)
 	in.KubeStateMetrics.DeepCopyInto(&out.KubeStateMetrics)
+	in.K8sPrometheusAdapter.DeepCopyInto(&out.K8sPrometheusAdapter)
 	in.MonitoringPlugin.DeepCopyInto(&out.MonitoringPlugin)
+	in.NodeExporter.DeepCopyInto(&out.NodeExporter)
 	in.OpenshiftStateMetrics.DeepCopyInto(&out.OpenshiftStateMetrics)
 	in.TelemeterClient.DeepCopyInto(&out.TelemeterClient)
 	in.MetricsServer.DeepCopyInto(&out.MetricsServer)
 	in.ThanosQuerier.DeepCopyInto(&out.ThanosQuerier)
+	in.PrometheusOperatorAdmissionWebhook.DeepCopyInto(&out.PrometheusOperatorAdmissionWebhook)
+	in.UserWorkload.DeepCopyInto(&out.UserWorkload)
+	if in.SnapshotBeforeChange != nil {
+		in, out := &in.SnapshotBeforeChange, &out.SnapshotBeforeChange
+		*out = new(SnapshotBeforeChange)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSpec.
@@ -250,6 +347,47 @@ func (in *ClusterSpec) DeepCopy() *ClusterSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 	*out = *in
+	if in.PVCStatus != nil {
+		in, out := &in.PVCStatus, &out.PVCStatus
+		*out = make([]PVCStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Snapshots != nil {
+		in, out := &in.Snapshots, &out.Snapshots
+		*out = make([]PVCSnapshot, len(*in))
+		copy(*out, *in)
+	}
+	if in.FieldOwners != nil {
+		in, out := &in.FieldOwners, &out.FieldOwners
+		*out = make([]FieldOwner, len(*in))
+		copy(*out, *in)
+	}
+	if in.Sizing != nil {
+		in, out := &in.Sizing, &out.Sizing
+		*out = make([]ComponentSizing, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AutoSizing != nil {
+		in, out := &in.AutoSizing, &out.AutoSizing
+		*out = new(AutoSizingStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UnresolvedVariables != nil {
+		in, out := &in.UnresolvedVariables, &out.UnresolvedVariables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStatus.
@@ -263,138 +401,417 @@ func (in *ClusterStatus) DeepCopy() *ClusterStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KubeStateMetrics) DeepCopyInto(out *KubeStateMetrics) {
+func (in *CommonPodSettings) DeepCopyInto(out *CommonPodSettings) {
 	*out = *in
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	in.PodSettings.DeepCopyInto(&out.PodSettings)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommonPodSettings.
+func (in *CommonPodSettings) DeepCopy() *CommonPodSettings {
+	if in == nil {
+		return nil
 	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	out := new(CommonPodSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentSizing) DeepCopyInto(out *ComponentSizing) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
 	}
-	if in.TopologySpreadConstraints != nil {
-		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
-		*out = make([]v1.TopologySpreadConstraint, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(resource.Quantity)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeStateMetrics.
-func (in *KubeStateMetrics) DeepCopy() *KubeStateMetrics {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentSizing.
+func (in *ComponentSizing) DeepCopy() *ComponentSizing {
 	if in == nil {
 		return nil
 	}
-	out := new(KubeStateMetrics)
+	out := new(ComponentSizing)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Metadata) DeepCopyInto(out *Metadata) {
+func (in *DedicatedServiceMonitors) DeepCopyInto(out *DedicatedServiceMonitors) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metadata.
-func (in *Metadata) DeepCopy() *Metadata {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DedicatedServiceMonitors.
+func (in *DedicatedServiceMonitors) DeepCopy() *DedicatedServiceMonitors {
 	if in == nil {
 		return nil
 	}
-	out := new(Metadata)
+	out := new(DedicatedServiceMonitors)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MetricsServer) DeepCopyInto(out *MetricsServer) {
+func (in *Exemplars) DeepCopyInto(out *Exemplars) {
 	*out = *in
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		*out = new(int64)
+		**out = **in
 	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Exemplars.
+func (in *Exemplars) DeepCopy() *Exemplars {
+	if in == nil {
+		return nil
 	}
-	if in.TopologySpreadConstraints != nil {
-		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
-		*out = make([]v1.TopologySpreadConstraint, len(*in))
+	out := new(Exemplars)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FieldOwner) DeepCopyInto(out *FieldOwner) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FieldOwner.
+func (in *FieldOwner) DeepCopy() *FieldOwner {
+	if in == nil {
+		return nil
+	}
+	out := new(FieldOwner)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FragmentStatus) DeepCopyInto(out *FragmentStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsServer.
-func (in *MetricsServer) DeepCopy() *MetricsServer {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FragmentStatus.
+func (in *FragmentStatus) DeepCopy() *FragmentStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MetricsServer)
+	out := new(FragmentStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MonitoringPlugin) DeepCopyInto(out *MonitoringPlugin) {
+func (in *K8sPrometheusAdapter) DeepCopyInto(out *K8sPrometheusAdapter) {
 	*out = *in
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	in.PodSettings.DeepCopyInto(&out.PodSettings)
+	if in.Audit != nil {
+		in, out := &in.Audit, &out.Audit
+		*out = new(Audit)
+		**out = **in
 	}
-	if in.TopologySpreadConstraints != nil {
-		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
-		*out = make([]v1.TopologySpreadConstraint, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.DedicatedServiceMonitors != nil {
+		in, out := &in.DedicatedServiceMonitors, &out.DedicatedServiceMonitors
+		*out = new(DedicatedServiceMonitors)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringPlugin.
-func (in *MonitoringPlugin) DeepCopy() *MonitoringPlugin {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new K8sPrometheusAdapter.
+func (in *K8sPrometheusAdapter) DeepCopy() *K8sPrometheusAdapter {
 	if in == nil {
 		return nil
 	}
-	out := new(MonitoringPlugin)
+	out := new(K8sPrometheusAdapter)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OpenshiftStateMetrics) DeepCopyInto(out *OpenshiftStateMetrics) {
+func (in *KubeStateMetrics) DeepCopyInto(out *KubeStateMetrics) {
 	*out = *in
-	if in.NodeSelector != nil {
+	in.PodSettings.DeepCopyInto(&out.PodSettings)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeStateMetrics.
+func (in *KubeStateMetrics) DeepCopy() *KubeStateMetrics {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeStateMetrics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Metadata) DeepCopyInto(out *Metadata) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metadata.
+func (in *Metadata) DeepCopy() *Metadata {
+	if in == nil {
+		return nil
+	}
+	out := new(Metadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetadataConfig) DeepCopyInto(out *MetadataConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetadataConfig.
+func (in *MetadataConfig) DeepCopy() *MetadataConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MetadataConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsServer) DeepCopyInto(out *MetricsServer) {
+	*out = *in
+	in.PodSettings.DeepCopyInto(&out.PodSettings)
+	if in.Audit != nil {
+		in, out := &in.Audit, &out.Audit
+		*out = new(Audit)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsServer.
+func (in *MetricsServer) DeepCopy() *MetricsServer {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoringPlugin) DeepCopyInto(out *MonitoringPlugin) {
+	*out = *in
+	in.PodSettings.DeepCopyInto(&out.PodSettings)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringPlugin.
+func (in *MonitoringPlugin) DeepCopy() *MonitoringPlugin {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoringPlugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeExporter) DeepCopyInto(out *NodeExporter) {
+	*out = *in
+	in.Collectors.DeepCopyInto(&out.Collectors)
+	if in.IgnoredNetworkDevices != nil {
+		in, out := &in.IgnoredNetworkDevices, &out.IgnoredNetworkDevices
+		*out = new([]string)
+		if **in != nil {
+			in, out := *in, *out
+			*out = make([]string, len(*in))
+			copy(*out, *in)
+		}
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeExporter.
+func (in *NodeExporter) DeepCopy() *NodeExporter {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeExporter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeExporterCollector) DeepCopyInto(out *NodeExporterCollector) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeExporterCollector.
+func (in *NodeExporterCollector) DeepCopy() *NodeExporterCollector {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeExporterCollector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeExporterCollectors) DeepCopyInto(out *NodeExporterCollectors) {
+	*out = *in
+	in.CPUFreq.DeepCopyInto(&out.CPUFreq)
+	in.TCPStat.DeepCopyInto(&out.TCPStat)
+	in.NetDev.DeepCopyInto(&out.NetDev)
+	in.NetClass.DeepCopyInto(&out.NetClass)
+	in.BuddyInfo.DeepCopyInto(&out.BuddyInfo)
+	in.MountStats.DeepCopyInto(&out.MountStats)
+	in.Ksmd.DeepCopyInto(&out.Ksmd)
+	in.Processes.DeepCopyInto(&out.Processes)
+	in.Systemd.DeepCopyInto(&out.Systemd)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeExporterCollectors.
+func (in *NodeExporterCollectors) DeepCopy() *NodeExporterCollectors {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeExporterCollectors)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeExporterNetClassCollector) DeepCopyInto(out *NodeExporterNetClassCollector) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.UseNetlink != nil {
+		in, out := &in.UseNetlink, &out.UseNetlink
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeExporterNetClassCollector.
+func (in *NodeExporterNetClassCollector) DeepCopy() *NodeExporterNetClassCollector {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeExporterNetClassCollector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeExporterSystemdCollector) DeepCopyInto(out *NodeExporterSystemdCollector) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Units != nil {
+		in, out := &in.Units, &out.Units
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeExporterSystemdCollector.
+func (in *NodeExporterSystemdCollector) DeepCopy() *NodeExporterSystemdCollector {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeExporterSystemdCollector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenshiftStateMetrics) DeepCopyInto(out *OpenshiftStateMetrics) {
+	*out = *in
+	in.PodSettings.DeepCopyInto(&out.PodSettings)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenshiftStateMetrics.
+func (in *OpenshiftStateMetrics) DeepCopy() *OpenshiftStateMetrics {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenshiftStateMetrics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PVCSnapshot) DeepCopyInto(out *PVCSnapshot) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCSnapshot.
+func (in *PVCSnapshot) DeepCopy() *PVCSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(PVCSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PVCStatus) DeepCopyInto(out *PVCStatus) {
+	*out = *in
+	if in.FailedPVCs != nil {
+		in, out := &in.FailedPVCs, &out.FailedPVCs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCStatus.
+func (in *PVCStatus) DeepCopy() *PVCStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PVCStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodSettings) DeepCopyInto(out *PodSettings) {
+	*out = *in
+	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
 		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
 		}
 	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Tolerations != nil {
 		in, out := &in.Tolerations, &out.Tolerations
 		*out = make([]v1.Toleration, len(*in))
@@ -411,12 +828,12 @@ func (in *OpenshiftStateMetrics) DeepCopyInto(out *OpenshiftStateMetrics) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenshiftStateMetrics.
-func (in *OpenshiftStateMetrics) DeepCopy() *OpenshiftStateMetrics {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodSettings.
+func (in *PodSettings) DeepCopy() *PodSettings {
 	if in == nil {
 		return nil
 	}
-	out := new(OpenshiftStateMetrics)
+	out := new(PodSettings)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -424,31 +841,57 @@ func (in *OpenshiftStateMetrics) DeepCopy() *OpenshiftStateMetrics {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Prometheus) DeepCopyInto(out *Prometheus) {
 	*out = *in
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
+	in.CommonPodSettings.DeepCopyInto(&out.CommonPodSettings)
+	if in.AdditionalAlertManagerConfigs != nil {
+		in, out := &in.AdditionalAlertManagerConfigs, &out.AdditionalAlertManagerConfigs
+		*out = make([]AdditionalAlertManagerConfigs, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnforcedLabelLimit != nil {
+		in, out := &in.EnforcedLabelLimit, &out.EnforcedLabelLimit
+		*out = new(int64)
+		**out = **in
+	}
+	if in.EnforcedLabelNameLengthLimit != nil {
+		in, out := &in.EnforcedLabelNameLengthLimit, &out.EnforcedLabelNameLengthLimit
+		*out = new(int64)
+		**out = **in
+	}
+	if in.EnforcedLabelValueLengthLimit != nil {
+		in, out := &in.EnforcedLabelValueLengthLimit, &out.EnforcedLabelValueLengthLimit
+		*out = new(int64)
+		**out = **in
+	}
+	if in.EnforcedSampleLimit != nil {
+		in, out := &in.EnforcedSampleLimit, &out.EnforcedSampleLimit
+		*out = new(int64)
+		**out = **in
+	}
+	if in.EnforcedTargetLimit != nil {
+		in, out := &in.EnforcedTargetLimit, &out.EnforcedTargetLimit
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ExternalLabels != nil {
+		in, out := &in.ExternalLabels, &out.ExternalLabels
 		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
 		}
 	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
+	if in.RemoteWrite != nil {
+		in, out := &in.RemoteWrite, &out.RemoteWrite
+		*out = make([]RemoteWriteSpec, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.Resources != nil {
-		in, out := &in.Resources, &out.Resources
-		*out = new(v1.ResourceRequirements)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.TopologySpreadConstraints != nil {
-		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
-		*out = make([]v1.TopologySpreadConstraint, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.RetentionSizePercent != nil {
+		in, out := &in.RetentionSizePercent, &out.RetentionSizePercent
+		*out = new(int32)
+		**out = **in
 	}
 	if in.VolumeClaimTemplate != nil {
 		in, out := &in.VolumeClaimTemplate, &out.VolumeClaimTemplate
@@ -467,9 +910,27 @@ func (in *Prometheus) DeepCopy() *Prometheus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusAutoSizing) DeepCopyInto(out *PrometheusAutoSizing) {
+	*out = *in
+	in.MinAllowed.DeepCopyInto(&out.MinAllowed)
+	in.MaxAllowed.DeepCopyInto(&out.MaxAllowed)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusAutoSizing.
+func (in *PrometheusAutoSizing) DeepCopy() *PrometheusAutoSizing {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusAutoSizing)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PrometheusK8S) DeepCopyInto(out *PrometheusK8S) {
 	*out = *in
+	in.CommonPodSettings.DeepCopyInto(&out.CommonPodSettings)
 	if in.AdditionalAlertManagerConfigs != nil {
 		in, out := &in.AdditionalAlertManagerConfigs, &out.AdditionalAlertManagerConfigs
 		*out = make([]AdditionalAlertManagerConfigs, len(*in))
@@ -477,6 +938,21 @@ func (in *PrometheusK8S) DeepCopyInto(out *PrometheusK8S) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.AutoSizing != nil {
+		in, out := &in.AutoSizing, &out.AutoSizing
+		*out = new(PrometheusAutoSizing)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EnforcedSampleLimit != nil {
+		in, out := &in.EnforcedSampleLimit, &out.EnforcedSampleLimit
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Exemplars != nil {
+		in, out := &in.Exemplars, &out.Exemplars
+		*out = new(Exemplars)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ExternalLabels != nil {
 		in, out := &in.ExternalLabels, &out.ExternalLabels
 		*out = make(map[string]string, len(*in))
@@ -484,28 +960,341 @@ func (in *PrometheusK8S) DeepCopyInto(out *PrometheusK8S) {
 			(*out)[key] = val
 		}
 	}
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
+	if in.RetentionSizePercent != nil {
+		in, out := &in.RetentionSizePercent, &out.RetentionSizePercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.VolumeClaimTemplate != nil {
+		in, out := &in.VolumeClaimTemplate, &out.VolumeClaimTemplate
+		*out = new(v1.PersistentVolumeClaimTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusK8S.
+func (in *PrometheusK8S) DeepCopy() *PrometheusK8S {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusK8S)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusOperator) DeepCopyInto(out *PrometheusOperator) {
+	*out = *in
+	in.CommonPodSettings.DeepCopyInto(&out.CommonPodSettings)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusOperator.
+func (in *PrometheusOperator) DeepCopy() *PrometheusOperator {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusOperator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusOperatorAdmissionWebhook) DeepCopyInto(out *PrometheusOperatorAdmissionWebhook) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusOperatorAdmissionWebhook.
+func (in *PrometheusOperatorAdmissionWebhook) DeepCopy() *PrometheusOperatorAdmissionWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusOperatorAdmissionWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueueConfig) DeepCopyInto(out *QueueConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueueConfig.
+func (in *QueueConfig) DeepCopy() *QueueConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(QueueConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RelabelConfig) DeepCopyInto(out *RelabelConfig) {
+	*out = *in
+	if in.SourceLabels != nil {
+		in, out := &in.SourceLabels, &out.SourceLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Separator != nil {
+		in, out := &in.Separator, &out.Separator
+		*out = new(string)
+		**out = **in
+	}
+	if in.Replacement != nil {
+		in, out := &in.Replacement, &out.Replacement
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RelabelConfig.
+func (in *RelabelConfig) DeepCopy() *RelabelConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RelabelConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteWriteSpec) DeepCopyInto(out *RemoteWriteSpec) {
+	*out = *in
+	if in.Authorization != nil {
+		in, out := &in.Authorization, &out.Authorization
+		*out = new(SafeAuthorization)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BasicAuth != nil {
+		in, out := &in.BasicAuth, &out.BasicAuth
+		*out = new(BasicAuth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
 		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
 		}
 	}
-	if in.Resources != nil {
-		in, out := &in.Resources, &out.Resources
-		*out = new(v1.ResourceRequirements)
+	if in.MetadataConfig != nil {
+		in, out := &in.MetadataConfig, &out.MetadataConfig
+		*out = new(MetadataConfig)
+		**out = **in
+	}
+	if in.QueueConfig != nil {
+		in, out := &in.QueueConfig, &out.QueueConfig
+		*out = new(QueueConfig)
+		**out = **in
+	}
+	if in.SendExemplars != nil {
+		in, out := &in.SendExemplars, &out.SendExemplars
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TLSConfig != nil {
+		in, out := &in.TLSConfig, &out.TLSConfig
+		*out = new(SafeTLSConfig)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
+	if in.WriteRelabelConfigs != nil {
+		in, out := &in.WriteRelabelConfigs, &out.WriteRelabelConfigs
+		*out = make([]RelabelConfig, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.TopologySpreadConstraints != nil {
-		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
-		*out = make([]v1.TopologySpreadConstraint, len(*in))
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteWriteSpec.
+func (in *RemoteWriteSpec) DeepCopy() *RemoteWriteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteWriteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SafeAuthorization) DeepCopyInto(out *SafeAuthorization) {
+	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SafeAuthorization.
+func (in *SafeAuthorization) DeepCopy() *SafeAuthorization {
+	if in == nil {
+		return nil
+	}
+	out := new(SafeAuthorization)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SafeTLSConfig) DeepCopyInto(out *SafeTLSConfig) {
+	*out = *in
+	in.CA.DeepCopyInto(&out.CA)
+	in.Cert.DeepCopyInto(&out.Cert)
+	if in.KeySecret != nil {
+		in, out := &in.KeySecret, &out.KeySecret
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SafeTLSConfig.
+func (in *SafeTLSConfig) DeepCopy() *SafeTLSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SafeTLSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretOrConfigMap) DeepCopyInto(out *SecretOrConfigMap) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretOrConfigMap.
+func (in *SecretOrConfigMap) DeepCopy() *SecretOrConfigMap {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretOrConfigMap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotBeforeChange) DeepCopyInto(out *SnapshotBeforeChange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotBeforeChange.
+func (in *SnapshotBeforeChange) DeepCopy() *SnapshotBeforeChange {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotBeforeChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Status) DeepCopyInto(out *Status) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Status.
+func (in *Status) DeepCopy() *Status {
+	if in == nil {
+		return nil
+	}
+	out := new(Status)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSConfig) DeepCopyInto(out *TLSConfig) {
+	*out = *in
+	if in.CA != nil {
+		in, out := &in.CA, &out.CA
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Cert != nil {
+		in, out := &in.Cert, &out.Cert
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Key != nil {
+		in, out := &in.Key, &out.Key
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSConfig.
+func (in *TLSConfig) DeepCopy() *TLSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TelemeterClient) DeepCopyInto(out *TelemeterClient) {
+	*out = *in
+	in.PodSettings.DeepCopyInto(&out.PodSettings)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TelemeterClient.
+func (in *TelemeterClient) DeepCopy() *TelemeterClient {
+	if in == nil {
+		return nil
+	}
+	out := new(TelemeterClient)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThanosQuerier) DeepCopyInto(out *ThanosQuerier) {
+	*out = *in
+	in.CommonPodSettings.DeepCopyInto(&out.CommonPodSettings)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThanosQuerier.
+func (in *ThanosQuerier) DeepCopy() *ThanosQuerier {
+	if in == nil {
+		return nil
+	}
+	out := new(ThanosQuerier)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThanosRuler) DeepCopyInto(out *ThanosRuler) {
+	*out = *in
+	in.CommonPodSettings.DeepCopyInto(&out.CommonPodSettings)
+	if in.AdditionalAlertManagerConfigs != nil {
+		in, out := &in.AdditionalAlertManagerConfigs, &out.AdditionalAlertManagerConfigs
+		*out = make([]AdditionalAlertManagerConfigs, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -517,220 +1306,286 @@ func (in *PrometheusK8S) DeepCopyInto(out *PrometheusK8S) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusK8S.
-func (in *PrometheusK8S) DeepCopy() *PrometheusK8S {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThanosRuler.
+func (in *ThanosRuler) DeepCopy() *ThanosRuler {
 	if in == nil {
 		return nil
 	}
-	out := new(PrometheusK8S)
+	out := new(ThanosRuler)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PrometheusOperator) DeepCopyInto(out *PrometheusOperator) {
+func (in *User) DeepCopyInto(out *User) {
 	*out = *in
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new User.
+func (in *User) DeepCopy() *User {
+	if in == nil {
+		return nil
 	}
-	if in.TopologySpreadConstraints != nil {
-		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
-		*out = make([]v1.TopologySpreadConstraint, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	out := new(User)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *User) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
 	}
+	return nil
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusOperator.
-func (in *PrometheusOperator) DeepCopy() *PrometheusOperator {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserFragment) DeepCopyInto(out *UserFragment) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserFragment.
+func (in *UserFragment) DeepCopy() *UserFragment {
 	if in == nil {
 		return nil
 	}
-	out := new(PrometheusOperator)
+	out := new(UserFragment)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *UserFragment) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Status) DeepCopyInto(out *Status) {
+func (in *UserFragmentList) DeepCopyInto(out *UserFragmentList) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]UserFragment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Status.
-func (in *Status) DeepCopy() *Status {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserFragmentList.
+func (in *UserFragmentList) DeepCopy() *UserFragmentList {
 	if in == nil {
 		return nil
 	}
-	out := new(Status)
+	out := new(UserFragmentList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *UserFragmentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TLSConfig) DeepCopyInto(out *TLSConfig) {
+func (in *UserFragmentSpec) DeepCopyInto(out *UserFragmentSpec) {
 	*out = *in
-	out.Ca = in.Ca
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Config.DeepCopyInto(&out.Config)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSConfig.
-func (in *TLSConfig) DeepCopy() *TLSConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserFragmentSpec.
+func (in *UserFragmentSpec) DeepCopy() *UserFragmentSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(TLSConfig)
+	out := new(UserFragmentSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TelemeterClient) DeepCopyInto(out *TelemeterClient) {
+func (in *UserList) DeepCopyInto(out *UserList) {
 	*out = *in
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]User, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TelemeterClient.
-func (in *TelemeterClient) DeepCopy() *TelemeterClient {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserList.
+func (in *UserList) DeepCopy() *UserList {
 	if in == nil {
 		return nil
 	}
-	out := new(TelemeterClient)
+	out := new(UserList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *UserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ThanosQuerier) DeepCopyInto(out *ThanosQuerier) {
+func (in *UserSpec) DeepCopyInto(out *UserSpec) {
 	*out = *in
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Resources != nil {
-		in, out := &in.Resources, &out.Resources
-		*out = new(v1.ResourceRequirements)
-		(*in).DeepCopyInto(*out)
+	in.Alertmanager.DeepCopyInto(&out.Alertmanager)
+	in.PrometheusOperator.DeepCopyInto(&out.PrometheusOperator)
+	in.Prometheus.DeepCopyInto(&out.Prometheus)
+	in.ThanosRuler.DeepCopyInto(&out.ThanosRuler)
+	if in.NamespacesWithoutLabelEnforcement != nil {
+		in, out := &in.NamespacesWithoutLabelEnforcement, &out.NamespacesWithoutLabelEnforcement
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.SnapshotBeforeChange != nil {
+		in, out := &in.SnapshotBeforeChange, &out.SnapshotBeforeChange
+		*out = new(SnapshotBeforeChange)
+		**out = **in
 	}
-	if in.TopologySpreadConstraints != nil {
-		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
-		*out = make([]v1.TopologySpreadConstraint, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.RequestPolicy != nil {
+		in, out := &in.RequestPolicy, &out.RequestPolicy
+		*out = new(UserWorkloadRequestPolicy)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThanosQuerier.
-func (in *ThanosQuerier) DeepCopy() *ThanosQuerier {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserSpec.
+func (in *UserSpec) DeepCopy() *UserSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ThanosQuerier)
+	out := new(UserSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ThanosRuler) DeepCopyInto(out *ThanosRuler) {
+func (in *UserStatus) DeepCopyInto(out *UserStatus) {
 	*out = *in
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
+	if in.PVCStatus != nil {
+		in, out := &in.PVCStatus, &out.PVCStatus
+		*out = make([]PVCStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
+	if in.Snapshots != nil {
+		in, out := &in.Snapshots, &out.Snapshots
+		*out = make([]PVCSnapshot, len(*in))
+		copy(*out, *in)
+	}
+	if in.MissingNamespaces != nil {
+		in, out := &in.MissingNamespaces, &out.MissingNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FieldOwners != nil {
+		in, out := &in.FieldOwners, &out.FieldOwners
+		*out = make([]FieldOwner, len(*in))
+		copy(*out, *in)
+	}
+	if in.Sizing != nil {
+		in, out := &in.Sizing, &out.Sizing
+		*out = make([]ComponentSizing, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.Resources != nil {
-		in, out := &in.Resources, &out.Resources
-		*out = new(v1.ResourceRequirements)
-		(*in).DeepCopyInto(*out)
+	if in.UnresolvedVariables != nil {
+		in, out := &in.UnresolvedVariables, &out.UnresolvedVariables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.TopologySpreadConstraints != nil {
-		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
-		*out = make([]v1.TopologySpreadConstraint, len(*in))
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.VolumeClaimTemplate != nil {
-		in, out := &in.VolumeClaimTemplate, &out.VolumeClaimTemplate
-		*out = new(v1.PersistentVolumeClaimTemplate)
-		(*in).DeepCopyInto(*out)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserStatus.
+func (in *UserStatus) DeepCopy() *UserStatus {
+	if in == nil {
+		return nil
 	}
+	out := new(UserStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThanosRuler.
-func (in *ThanosRuler) DeepCopy() *ThanosRuler {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserWorkload) DeepCopyInto(out *UserWorkload) {
+	*out = *in
+	if in.RulesWithoutLabelEnforcementAllowed != nil {
+		in, out := &in.RulesWithoutLabelEnforcementAllowed, &out.RulesWithoutLabelEnforcementAllowed
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserWorkload.
+func (in *UserWorkload) DeepCopy() *UserWorkload {
 	if in == nil {
 		return nil
 	}
-	out := new(ThanosRuler)
+	out := new(UserWorkload)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *User) DeepCopyInto(out *User) {
+func (in *UserWorkloadRequest) DeepCopyInto(out *UserWorkloadRequest) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new User.
-func (in *User) DeepCopy() *User {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserWorkloadRequest.
+func (in *UserWorkloadRequest) DeepCopy() *UserWorkloadRequest {
 	if in == nil {
 		return nil
 	}
-	out := new(User)
+	out := new(UserWorkloadRequest)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *User) DeepCopyObject() runtime.Object {
+func (in *UserWorkloadRequest) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -738,31 +1593,31 @@ func (in *User) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *UserList) DeepCopyInto(out *UserList) {
+func (in *UserWorkloadRequestList) DeepCopyInto(out *UserWorkloadRequestList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]User, len(*in))
+		*out = make([]UserWorkloadRequest, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserList.
-func (in *UserList) DeepCopy() *UserList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserWorkloadRequestList.
+func (in *UserWorkloadRequestList) DeepCopy() *UserWorkloadRequestList {
 	if in == nil {
 		return nil
 	}
-	out := new(UserList)
+	out := new(UserWorkloadRequestList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *UserList) DeepCopyObject() runtime.Object {
+func (in *UserWorkloadRequestList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -770,35 +1625,78 @@ func (in *UserList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *UserSpec) DeepCopyInto(out *UserSpec) {
+func (in *UserWorkloadRequestPolicy) DeepCopyInto(out *UserWorkloadRequestPolicy) {
 	*out = *in
-	in.Alertmanager.DeepCopyInto(&out.Alertmanager)
-	in.PrometheusOperator.DeepCopyInto(&out.PrometheusOperator)
-	in.Prometheus.DeepCopyInto(&out.Prometheus)
-	in.ThanosRuler.DeepCopyInto(&out.ThanosRuler)
+	if in.AllowedFields != nil {
+		in, out := &in.AllowedFields, &out.AllowedFields
+		*out = make([]UserWorkloadRequestField, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxEnforcedSampleLimit != nil {
+		in, out := &in.MaxEnforcedSampleLimit, &out.MaxEnforcedSampleLimit
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxEnforcedTargetLimit != nil {
+		in, out := &in.MaxEnforcedTargetLimit, &out.MaxEnforcedTargetLimit
+		*out = new(int64)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserSpec.
-func (in *UserSpec) DeepCopy() *UserSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserWorkloadRequestPolicy.
+func (in *UserWorkloadRequestPolicy) DeepCopy() *UserWorkloadRequestPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(UserSpec)
+	out := new(UserWorkloadRequestPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *UserStatus) DeepCopyInto(out *UserStatus) {
+func (in *UserWorkloadRequestSpec) DeepCopyInto(out *UserWorkloadRequestSpec) {
 	*out = *in
+	if in.EnforcedSampleLimit != nil {
+		in, out := &in.EnforcedSampleLimit, &out.EnforcedSampleLimit
+		*out = new(int64)
+		**out = **in
+	}
+	if in.EnforcedTargetLimit != nil {
+		in, out := &in.EnforcedTargetLimit, &out.EnforcedTargetLimit
+		*out = new(int64)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserStatus.
-func (in *UserStatus) DeepCopy() *UserStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserWorkloadRequestSpec.
+func (in *UserWorkloadRequestSpec) DeepCopy() *UserWorkloadRequestSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(UserStatus)
+	out := new(UserWorkloadRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserWorkloadRequestStatus) DeepCopyInto(out *UserWorkloadRequestStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserWorkloadRequestStatus.
+func (in *UserWorkloadRequestStatus) DeepCopy() *UserWorkloadRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UserWorkloadRequestStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -0,0 +1,224 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+const (
+	// autoSizingInterval is how often auto-sized resources are re-evaluated, as
+	// the scale of the cluster does not trigger a reconcile.
+	autoSizingInterval = 15 * time.Minute
+	// prometheusTSDBStatusURL is the TSDB status API of the platform Prometheus.
+	prometheusTSDBStatusURL = "https://prometheus-k8s.openshift-monitoring.svc:9091/api/v1/status/tsdb"
+	// serviceCAFile is the CA of the OpenShift service serving certificates.
+	serviceCAFile = "/var/run/secrets/kubernetes.io/serviceaccount/service-ca.crt"
+	// prometheusQueryTimeout bounds the head series query, so an unresponsive
+	// Prometheus cannot hold up the reconcile.
+	prometheusQueryTimeout = 10 * time.Second
+)
+
+// clusterScale is what Prometheus is auto-sized from.
+type clusterScale struct {
+	nodes, pods int64
+	// headSeries is nil unless it was queried successfully.
+	headSeries *int64
+}
+
+// observeClusterScale counts the nodes and pods, and queries the head series
+// through prometheus if asked to. Without a client, or if the query fails, it
+// falls back to sizing from nodes and pods.
+func observeClusterScale(ctx context.Context, c client.Reader, prometheus *http.Client, headSeries bool) (clusterScale, error) {
+	var scale clusterScale
+	var err error
+	if scale.nodes, err = countObjects(ctx, c, "NodeList"); err != nil {
+		return scale, err
+	}
+	if scale.pods, err = countObjects(ctx, c, "PodList"); err != nil {
+		return scale, err
+	}
+	if !headSeries {
+		return scale, nil
+	}
+	if prometheus == nil {
+		log.FromContext(ctx).Info("No Prometheus client, sizing from nodes and pods")
+		return scale, nil
+	}
+	queryContext, cancel := context.WithTimeout(ctx, prometheusQueryTimeout)
+	defer cancel()
+	series, err := queryHeadSeries(queryContext, prometheus, prometheusTSDBStatusURL)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Unable to query Prometheus head series, sizing from nodes and pods")
+		return scale, nil
+	}
+	scale.headSeries = &series
+	return scale, nil
+}
+
+// countObjects counts the core objects of a list kind by listing the metadata
+// of a single one, as the API server returns how many remain.
+func countObjects(ctx context.Context, c client.Reader, listKind string) (int64, error) {
+	list := &metav1.PartialObjectMetadataList{}
+	list.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind(listKind))
+	if err := c.List(ctx, list, client.Limit(1)); err != nil {
+		return 0, err
+	}
+	count := int64(len(list.Items))
+	if remaining := list.GetRemainingItemCount(); remaining != nil {
+		count += *remaining
+	}
+	return count, nil
+}
+
+// prometheusClient authenticates as the controller's service account and
+// trusts the service CA, which signs the Prometheus serving certificate. It is
+// built once, when the controller is set up.
+func prometheusClient() (*http.Client, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.TLSClientConfig = rest.TLSClientConfig{CAFile: serviceCAFile}
+	config.Timeout = prometheusQueryTimeout
+	return rest.HTTPClientFor(config)
+}
+
+// tsdbStatus is the part of the Prometheus TSDB status API response read.
+type tsdbStatus struct {
+	Data struct {
+		HeadStats struct {
+			NumSeries int64 `json:"numSeries"`
+		} `json:"headStats"`
+	} `json:"data"`
+}
+
+// queryHeadSeries returns the number of series in the head block of Prometheus.
+func queryHeadSeries(ctx context.Context, httpClient *http.Client, url string) (int64, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("GET %s: %s", url, response.Status)
+	}
+	var status tsdbStatus
+	if err := json.NewDecoder(response.Body).Decode(&status); err != nil {
+		return 0, err
+	}
+	return status.Data.HeadStats.NumSeries, nil
+}
+
+// autoSizedRequests estimates the requests of Prometheus: a base plus a share
+// per node and pod, and for memory at least a share per head series. They are
+// rounded up to steps of 50m and 256Mi, which absorbs small changes in scale.
+func autoSizedRequests(scale clusterScale) corev1.ResourceList {
+	milliCPU := 100 + 10*scale.nodes + scale.pods/10
+	memory := 32<<20*scale.nodes + 1<<20*scale.pods
+	if scale.headSeries != nil {
+		series := *scale.headSeries
+		memory = max(memory, series*8<<10)
+	}
+	memory += 1 << 30
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewMilliQuantity(roundUp(milliCPU, 50), resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(roundUp(memory, 256<<20), resource.BinarySI),
+	}
+}
+
+func roundUp(value, step int64) int64 {
+	return (value + step - 1) / step * step
+}
+
+// boundRequests raises the requests to minAllowed and lowers them to
+// maxAllowed.
+func boundRequests(requests, minAllowed, maxAllowed corev1.ResourceList) {
+	for name, value := range requests {
+		if floor, ok := minAllowed[name]; ok && value.Cmp(floor) < 0 {
+			value = floor.DeepCopy()
+		}
+		if ceiling, ok := maxAllowed[name]; ok && value.Cmp(ceiling) > 0 {
+			value = ceiling.DeepCopy()
+		}
+		requests[name] = value
+	}
+}
+
+// withinTolerance reports whether the current requests are within the bounds
+// and each of the computed requests is within tolerance percent of them.
+func withinTolerance(requests, current corev1.ResourceList, autoSizing *monitoringv1.PrometheusAutoSizing) bool {
+	bounded := current.DeepCopy()
+	boundRequests(bounded, autoSizing.MinAllowed, autoSizing.MaxAllowed)
+	if len(current) != len(requests) || !equality.Semantic.DeepEqual(bounded, current) {
+		return false
+	}
+	for name, value := range requests {
+		previous, ok := current[name]
+		if !ok {
+			return false
+		}
+		drift := math.Abs(value.AsApproximateFloat64() - previous.AsApproximateFloat64())
+		if drift > previous.AsApproximateFloat64()*float64(autoSizing.TolerancePercent)/100 {
+			return false
+		}
+	}
+	return true
+}
+
+// autoSizePrometheus sizes Prometheus for scale, keeping the resources of the
+// previous status while the requests stay within the tolerance, so changes in
+// scale do not keep restarting Prometheus.
+func autoSizePrometheus(previous *monitoringv1.AutoSizingStatus, autoSizing *monitoringv1.PrometheusAutoSizing, scale clusterScale, now metav1.Time) *monitoringv1.AutoSizingStatus {
+	status := &monitoringv1.AutoSizingStatus{Nodes: scale.nodes, Pods: scale.pods, HeadSeries: scale.headSeries}
+	requests := autoSizedRequests(scale)
+	boundRequests(requests, autoSizing.MinAllowed, autoSizing.MaxAllowed)
+	if previous != nil && withinTolerance(requests, previous.Resources.Requests, autoSizing) {
+		status.Resources = *previous.Resources.DeepCopy()
+		status.LastResizeTime = previous.LastResizeTime
+		return status
+	}
+
+	limits := corev1.ResourceList{}
+	for name, value := range requests {
+		limit := value.DeepCopy()
+		limit.Add(value)
+		limits[name] = limit
+	}
+	status.Resources = corev1.ResourceRequirements{Requests: requests, Limits: limits}
+	status.LastResizeTime = &now
+	return status
+}
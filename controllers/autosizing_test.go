@@ -0,0 +1,236 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+func TestAutoSizePrometheus(t *testing.T) {
+	resources := func(cpu, memory string) corev1.ResourceRequirements {
+		requests := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory)}
+		limits := corev1.ResourceList{}
+		for name, value := range requests {
+			limit := value.DeepCopy()
+			limit.Add(value)
+			limits[name] = limit
+		}
+		return corev1.ResourceRequirements{Requests: requests, Limits: limits}
+	}
+	lastResize := metav1.NewTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	now := metav1.NewTime(lastResize.Add(time.Hour))
+	series := int64(500000)
+
+	tests := []struct {
+		name       string
+		previous   *monitoringv1.AutoSizingStatus
+		autoSizing monitoringv1.PrometheusAutoSizing
+		scale      clusterScale
+		want       corev1.ResourceRequirements
+		resized    bool
+	}{
+		{
+			name:    "nodes and pods",
+			scale:   clusterScale{nodes: 3, pods: 10},
+			want:    resources("150m", "1280Mi"),
+			resized: true,
+		},
+		{
+			name:    "head series",
+			scale:   clusterScale{nodes: 3, pods: 10, headSeries: &series},
+			want:    resources("150m", "5Gi"),
+			resized: true,
+		},
+		{
+			name: "bounded",
+			autoSizing: monitoringv1.PrometheusAutoSizing{
+				MinAllowed: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+				MaxAllowed: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+			},
+			scale:   clusterScale{nodes: 3, pods: 10},
+			want:    resources("100m", "2Gi"),
+			resized: true,
+		},
+		{
+			name:     "within tolerance",
+			previous: &monitoringv1.AutoSizingStatus{Resources: resources("150m", "1200Mi"), LastResizeTime: &lastResize},
+			scale:    clusterScale{nodes: 3, pods: 10},
+			want:     resources("150m", "1200Mi"),
+		},
+		{
+			name:     "beyond tolerance",
+			previous: &monitoringv1.AutoSizingStatus{Resources: resources("150m", "1Gi"), LastResizeTime: &lastResize},
+			scale:    clusterScale{nodes: 3, pods: 10},
+			want:     resources("150m", "1280Mi"),
+			resized:  true,
+		},
+		{
+			name: "previous out of bounds",
+			autoSizing: monitoringv1.PrometheusAutoSizing{
+				MaxAllowed: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1152Mi")},
+			},
+			previous: &monitoringv1.AutoSizingStatus{Resources: resources("150m", "1200Mi"), LastResizeTime: &lastResize},
+			scale:    clusterScale{nodes: 3, pods: 10},
+			want:     resources("150m", "1152Mi"),
+			resized:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.autoSizing.TolerancePercent = 10
+			status := autoSizePrometheus(tt.previous, &tt.autoSizing, tt.scale, now)
+			if !equality.Semantic.DeepEqual(status.Resources, tt.want) {
+				t.Errorf("resources = %v, want %v", status.Resources, tt.want)
+			}
+			if resized := status.LastResizeTime.Equal(&now); resized != tt.resized {
+				t.Errorf("resized = %t, want %t", resized, tt.resized)
+			}
+		})
+	}
+}
+
+func TestQueryHeadSeries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/status/tsdb" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{"status":"success","data":{"headStats":{"numSeries":1234,"chunkCount":5678}}}`)
+	}))
+	defer server.Close()
+
+	series, err := queryHeadSeries(context.Background(), server.Client(), server.URL+"/api/v1/status/tsdb")
+	if err != nil || series != 1234 {
+		t.Errorf("queryHeadSeries = %d, %v, want 1234", series, err)
+	}
+	if _, err := queryHeadSeries(context.Background(), server.Client(), server.URL+"/api/v1/status"); err == nil {
+		t.Error("queryHeadSeries of a forbidden URL succeeded")
+	}
+}
+
+// roundTripFunc answers the requests of an http.Client without a server.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) { return f(request) }
+
+func TestObserveClusterScale(t *testing.T) {
+	respond := func(status int, body string) *http.Client {
+		return &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: status, Status: http.StatusText(status), Body: io.NopCloser(strings.NewReader(body)), Request: request}, nil
+		})}
+	}
+	hang := &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		<-request.Context().Done()
+		return nil, request.Context().Err()
+	}), Timeout: 10 * time.Millisecond}
+
+	tests := []struct {
+		name       string
+		prometheus *http.Client
+		headSeries bool
+		want       *int64
+	}{
+		{name: "head series not asked for", prometheus: respond(http.StatusOK, `{"data":{"headStats":{"numSeries":1234}}}`)},
+		{name: "head series", prometheus: respond(http.StatusOK, `{"data":{"headStats":{"numSeries":1234}}}`), headSeries: true, want: int64Pointer(1234)},
+		{name: "no client", headSeries: true},
+		{name: "failed query", prometheus: respond(http.StatusServiceUnavailable, ""), headSeries: true},
+		{name: "timed out query", prometheus: hang, headSeries: true},
+	}
+	c := newFakeClient(t,
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod"}},
+	)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scale, err := observeClusterScale(context.Background(), c, tt.prometheus, tt.headSeries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if scale.nodes != 1 || scale.pods != 1 {
+				t.Errorf("scale = %d nodes and %d pods, want 1 and 1", scale.nodes, scale.pods)
+			}
+			if (scale.headSeries == nil) != (tt.want == nil) || (tt.want != nil && *scale.headSeries != *tt.want) {
+				t.Errorf("headSeries = %v, want %v", scale.headSeries, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonitoringReconcilerAutoSizes(t *testing.T) {
+	cluster := &monitoringv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-monitoring-config"},
+		Spec: monitoringv1.ClusterSpec{
+			PrometheusK8S: monitoringv1.PrometheusK8S{
+				CommonPodSettings: monitoringv1.CommonPodSettings{
+					PodSettings: monitoringv1.PodSettings{
+						Resources: &corev1.ResourceRequirements{
+							Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("6Gi")},
+						},
+					},
+				},
+				AutoSizing: &monitoringv1.PrometheusAutoSizing{TolerancePercent: 10},
+			},
+		},
+	}
+	objects := []client.Object{cluster}
+	for i := 0; i < 3; i++ {
+		objects = append(objects, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", i)}})
+	}
+	for i := 0; i < 10; i++ {
+		objects = append(objects, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("pod-%d", i)}})
+	}
+	c := newFakeClient(t, objects...)
+	target := clusterTarget{}
+	if result := reconcileTarget(t, c, target); result.RequeueAfter != autoSizingInterval {
+		t.Errorf("requeueAfter = %s, want %s", result.RequeueAfter, autoSizingInterval)
+	}
+
+	// The memory limit set in the spec wins over the auto-sized memory
+	want := `prometheusK8s:
+  resources:
+    limits:
+      cpu: 300m
+      memory: 6Gi
+    requests:
+      cpu: 150m
+`
+	if got := renderedConfig(t, c, target); got != want {
+		t.Errorf("config.yaml = %q, want %q", got, want)
+	}
+
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(cluster), cluster); err != nil {
+		t.Fatal(err)
+	}
+	if status := cluster.Status.AutoSizing; status == nil || status.Nodes != 3 || status.Pods != 10 || status.LastResizeTime == nil {
+		t.Errorf("status.autoSizing = %+v, want 3 nodes and 10 pods", status)
+	}
+}
@@ -18,183 +18,235 @@ package controllers
 
 import (
 	"context"
-	"strings"
+	"net/http"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
-	"sigs.k8s.io/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
-	monitoringv1beta1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1beta1"
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
 )
 
 // ClusterReconciler reconciles a Cluster object
 type ClusterReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// prometheus queries the head series for auto-sizing, nil when the
+	// controller runs outside the cluster.
+	prometheus *http.Client
 }
 
 //+kubebuilder:rbac:groups=monitoring.arthurvardevanyan.com,resources=clusters,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=monitoring.arthurvardevanyan.com,resources=clusters/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=monitoring.arthurvardevanyan.com,resources=clusters/finalizers,verbs=update
-
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the Cluster object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
-//
-// For more details, check Reconcile and its Result here:
-// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.1/pkg/reconcile
+//+kubebuilder:rbac:groups=monitoring.arthurvardevanyan.com,resources=clusterfragments,verbs=get;list;watch
+//+kubebuilder:rbac:groups=monitoring.arthurvardevanyan.com,resources=clusterfragments/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=config.openshift.io,resources=clusterversions,verbs=get
+//+kubebuilder:rbac:groups=config.openshift.io,resources=infrastructures,verbs=get
+//+kubebuilder:rbac:groups="",resources=nodes;pods,verbs=list
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheuses/api,resourceNames=k8s,verbs=get
+
+// Reconcile renders the Cluster CR, with its ClusterFragments merged in, into
+// the cluster-monitoring-config ConfigMap in openshift-monitoring, auto-sizing
+// prometheusK8s if asked to. See monitoringReconciler.Reconcile.
 func (r *ClusterReconciler) Reconcile(reconcilerContext context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := log.FromContext(reconcilerContext)
-	log.V(1).Info(req.Name)
-
-	// Incept Object
-	var monitoring monitoringv1beta1.Cluster
-	if err := r.Get(reconcilerContext, req.NamespacedName, &monitoring); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			log.V(1).Info("Monitoring Config Not Found or No Longer Exists!")
-			return ctrl.Result{}, nil
-		} else {
-			log.Error(err, "Unable to fetch Monitoring Object")
-			return ctrl.Result{}, client.IgnoreNotFound(err)
-		}
-	}
+	return (&monitoringReconciler{Client: r.Client, target: clusterTarget{prometheus: r.prometheus}}).Reconcile(reconcilerContext, req)
+}
 
-	const (
-		finalizer     string = "cluster.monitoring.arthurvardevanyan.com/finalizer"
-		namespace     string = "openshift-monitoring"
-		configMapName string = "cluster-monitoring-config"
-	)
+// clusterTarget renders the Cluster CR into the platform monitoring ConfigMap.
+type clusterTarget struct {
+	// prometheus is the client of the head series query, see observeClusterScale.
+	prometheus *http.Client
+}
 
-	if req.Name != configMapName {
-		log.V(1).Info("Invalid Object Name!")
-		r.Delete(reconcilerContext, &monitoring)
-		return ctrl.Result{}, nil
+func (clusterTarget) newObject() client.Object { return &monitoringv1.Cluster{} }
+func (clusterTarget) finalizer() string        { return "cluster.monitoring.arthurvardevanyan.com/finalizer" }
+func (clusterTarget) namespace() string        { return "openshift-monitoring" }
+func (clusterTarget) configMapName() string    { return "cluster-monitoring-config" }
+
+// requests is a no-op, as tenants only request user workload settings.
+func (clusterTarget) requests(context.Context, client.Reader, client.Object) (appliedRequests, error) {
+	return appliedRequests{}, nil
+}
+
+// sizing leaves out a disabled Alertmanager, so the preset does not add a
+// section for it.
+func (clusterTarget) sizing(object client.Object) (monitoringv1.Sizing, []sizedComponent) {
+	spec := &object.(*monitoringv1.Cluster).Spec
+	components := []sizedComponent{
+		{path: "prometheusK8s", kind: "prometheus", resources: &spec.PrometheusK8S.Resources, vct: &spec.PrometheusK8S.VolumeClaimTemplate, retention: &spec.PrometheusK8S.Retention},
 	}
+	if spec.AlertmanagerMain.Enabled == nil || *spec.AlertmanagerMain.Enabled {
+		components = append(components, sizedComponent{path: "alertmanagerMain", kind: "alertmanager", resources: &spec.AlertmanagerMain.Resources, vct: &spec.AlertmanagerMain.VolumeClaimTemplate})
+	}
+	components = append(components, sizedComponent{path: "thanosQuerier", kind: "thanosQuerier", resources: &spec.ThanosQuerier.Resources})
+	return spec.Sizing, components
+}
 
-	configMapData := make(map[string]string)
-	MonitoringYaml, err := yaml.Marshal(&monitoring.Spec)
+// autoSize sizes prometheusK8s while spec.prometheusK8s.autoSizing is set.
+func (t clusterTarget) autoSize(ctx context.Context, c client.Reader, object client.Object) (*monitoringv1.AutoSizingStatus, error) {
+	monitoring := object.(*monitoringv1.Cluster)
+	prometheus := &monitoring.Spec.PrometheusK8S
+	if prometheus.AutoSizing == nil {
+		return nil, nil
+	}
+	scale, err := observeClusterScale(ctx, c, t.prometheus, prometheus.AutoSizing.HeadSeries)
 	if err != nil {
-		log.Error(err, "Unable to Marshal ConfigMap Struct to Yaml!")
-		return ctrl.Result{}, err
+		return nil, err
 	}
-	configMapData["config.yaml"] = string(MonitoringYaml)
+	status := autoSizePrometheus(monitoring.Status.AutoSizing, prometheus.AutoSizing, scale, metav1.Now())
+	fillResources(&prometheus.Resources, status.Resources)
+	return status, nil
+}
 
-	// Parse the YAML string into a map
-	var parsedData map[string]interface{}
-	err = yaml.Unmarshal([]byte(configMapData["config.yaml"]), &parsedData)
-	if err != nil {
-		log.Error(err, "Unable to Unmarshal ConfigMap Yaml to Struct!")
-		return ctrl.Result{}, err
+func (clusterTarget) status(object client.Object) targetStatus {
+	monitoring := object.(*monitoringv1.Cluster)
+	return targetStatus{
+		pvcStatus:           &monitoring.Status.PVCStatus,
+		snapshots:           &monitoring.Status.Snapshots,
+		conditions:          &monitoring.Status.Conditions,
+		fieldOwners:         &monitoring.Status.FieldOwners,
+		sizing:              &monitoring.Status.Sizing,
+		autoSizing:          &monitoring.Status.AutoSizing,
+		unresolvedVariables: &monitoring.Status.UnresolvedVariables,
 	}
+}
 
-	removeMetadata(parsedData)
+func (clusterTarget) spec(object client.Object) interface{} {
+	return &object.(*monitoringv1.Cluster).Spec
+}
 
-	// Convert the modified map back to YAML
-	modifiedYaml, err := yaml.Marshal(parsedData)
-	if err != nil {
-		log.Error(err, "Unable to Marshal Modified ConfigMap Struct to Yaml!")
-		return ctrl.Result{}, err
-	}
-	configMapData["config.yaml"] = string(modifiedYaml)
-
-	var ownerRef = metav1.OwnerReference{
-		APIVersion:         monitoring.APIVersion,
-		Kind:               monitoring.Kind,
-		Name:               monitoring.Name,
-		UID:                monitoring.UID,
-		Controller:         BoolPointer(true),
-		BlockOwnerDeletion: BoolPointer(true),
-	}
-	ownerReference := []metav1.OwnerReference{ownerRef}
-
-	configMap := corev1.ConfigMap{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       "ConfigMap",
-			APIVersion: "v1",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            configMapName,
-			Namespace:       namespace,
-			OwnerReferences: ownerReference,
-		},
-		Data: configMapData,
-	}
-
-	// Delete Logic, Create / Update Finalizers, and Garbage Collect LogSink on Object Deletion
-	// https://book.kubebuilder.io/reference/using-finalizers.html
-	if monitoring.ObjectMeta.DeletionTimestamp.IsZero() {
-		// The object is not being deleted, so if it does not have our finalizer,
-		// then lets add the finalizer and update the object. This is equivalent
-		// registering our finalizer.
-		if !controllerutil.ContainsFinalizer(&monitoring, finalizer) {
-			// https://sdk.operatorframework.io/docs/building-operators/golang/references/client/#patch
-			patch := client.MergeFrom(monitoring.DeepCopy())
-			controllerutil.AddFinalizer(&monitoring, finalizer)
-			// https://sdk.operatorframework.io/docs/building-operators/golang/references/client/#patch
-			if err := r.Patch(reconcilerContext, &monitoring, patch); err != nil {
-				return ctrl.Result{}, err
-			} else {
-				return ctrl.Result{}, nil
-			}
-		}
-	} else {
-		// The object is being deleted
-		if controllerutil.ContainsFinalizer(&monitoring, finalizer) {
-			// our finalizer is present, so lets handle any external dependency
-			log.V(1).Info("Deleting ConfigMap!")
-			err := r.Delete(reconcilerContext, &configMap)
-			if err != nil {
-				log.Error(err, "Unable to Delete ConfigMap!")
-				return ctrl.Result{}, err
-			}
+func (clusterTarget) fragments(ctx context.Context, c client.Reader) ([]fragment, error) {
+	var list monitoringv1.ClusterFragmentList
+	if err := c.List(ctx, &list); err != nil {
+		return nil, err
+	}
+	fragments := make([]fragment, 0, len(list.Items))
+	for i := range list.Items {
+		item := &list.Items[i]
+		fragments = append(fragments, fragment{
+			object:     item,
+			kind:       "ClusterFragment",
+			priority:   item.Spec.Priority,
+			components: item.Spec.Components,
+			config:     &item.Spec.Config,
+			conditions: &item.Status.Conditions,
+		})
+	}
+	return fragments, nil
+}
 
-			// remove our finalizer from the list and update it.
-			patch := client.MergeFrom(monitoring.DeepCopy())
-			controllerutil.RemoveFinalizer(&monitoring, finalizer)
-			if err := r.Patch(reconcilerContext, &monitoring, patch); err != nil {
-				return ctrl.Result{}, err
-			}
-		}
+func (clusterTarget) components(object client.Object) []pvcComponent {
+	monitoring := object.(*monitoringv1.Cluster)
+	return []pvcComponent{
+		{statefulSet: "prometheus-k8s", vct: monitoring.Spec.PrometheusK8S.VolumeClaimTemplate},
+		{statefulSet: "alertmanager-main", vct: monitoring.Spec.AlertmanagerMain.VolumeClaimTemplate},
+	}
+}
 
-		// Stop reconciliation as the item is being deleted
-		return ctrl.Result{}, nil
+func (clusterTarget) snapshot(object client.Object) *monitoringv1.SnapshotBeforeChange {
+	return object.(*monitoringv1.Cluster).Spec.SnapshotBeforeChange
+}
+
+func (t clusterTarget) validate(ctx context.Context, c client.Reader, object client.Object) (field.ErrorList, bool, error) {
+	monitoring := object.(*monitoringv1.Cluster)
+	errs := validateClusterSpec(&monitoring.Spec)
+	secretErrs, err := validateSecrets(ctx, c, field.NewPath("spec", "alertmanagerMain", "secrets"), t.namespace(), monitoring.Spec.AlertmanagerMain.Secrets)
+	if err != nil {
+		return nil, false, err
 	}
+	refs := alertmanagerConfigSecretRefs(field.NewPath("spec", "prometheusK8s", "additionalAlertmanagerConfigs"), monitoring.Spec.PrometheusK8S.AdditionalAlertManagerConfigs)
+	if ref := monitoring.Spec.TelemeterClient.TokenSecret; ref != nil {
+		refs = append(refs, secretKeyRef{path: field.NewPath("spec", "telemeterClient", "tokenSecret"), selector: ref})
+	}
+	keyErrs, err := validateSecretKeys(ctx, c, t.namespace(), refs)
+	if err != nil {
+		return nil, false, err
+	}
+	secretErrs = append(secretErrs, keyErrs...)
+	return append(errs, secretErrs...), len(secretErrs) > 0, nil
+}
+
+// warnings does not fail if the OpenShift version or the User CR cannot be
+// read, it only skips the checks depending on them.
+func (clusterTarget) warnings(ctx context.Context, c client.Reader, object client.Object) ([]string, error) {
+	log := log.FromContext(ctx)
+	monitoring := object.(*monitoringv1.Cluster)
 
-	// https://medium.com/@aneeshputtur/kubernetes-operators-with-external-configmap-b972c9c36bbe
-	err = r.Create(reconcilerContext, &configMap)
+	openshift, err := openshiftVersion(ctx, c)
 	if err != nil {
-		log.V(1).Info("Update ConfigMap")
-		r.Update(reconcilerContext, &configMap)
-	} else {
-		log.V(1).Info("Create ConfigMap")
+		log.Error(err, "Unable to determine OpenShift version")
 	}
+	var userSpec *monitoringv1.UserSpec
+	var user monitoringv1.User
+	if err := getMerged(ctx, c, userTarget{}, &user); err == nil {
+		userSpec = &user.Spec
+	} else if !apierrors.IsNotFound(err) {
+		log.Error(err, "Unable to fetch User Monitoring Object")
+	}
+	return clusterWarnings(&monitoring.Spec, openshift, userSpec), nil
+}
 
-	// Reconcile PVC sizes to match volumeClaimTemplate
-	if monitoring.Spec.PrometheusK8S.VolumeClaimTemplate != nil {
-		if err := reconcilePVCSize(reconcilerContext, r.Client, namespace, "prometheus-k8s-db-prometheus-k8s-", monitoring.Spec.PrometheusK8S.VolumeClaimTemplate); err != nil {
-			log.Error(err, "Unable to reconcile Prometheus PVC sizes")
-		}
+func (t clusterTarget) render(ctx context.Context, c client.Reader, object client.Object, capacities map[string]*resource.Quantity) (interface{}, error) {
+	monitoring := object.(*monitoringv1.Cluster)
+	spec := monitoring.Spec.DeepCopy()
+	if spec.PrometheusK8S.RetentionSize == "" {
+		spec.PrometheusK8S.RetentionSize = retentionSize(spec.PrometheusK8S.RetentionSizePercent, capacities["prometheus-k8s"], spec.PrometheusK8S.VolumeClaimTemplate)
 	}
-	if monitoring.Spec.AlertmanagerMain.VolumeClaimTemplate != nil {
-		if err := reconcilePVCSize(reconcilerContext, r.Client, namespace, "alertmanager-main-db-alertmanager-main-", monitoring.Spec.AlertmanagerMain.VolumeClaimTemplate); err != nil {
-			log.Error(err, "Unable to reconcile Alertmanager PVC sizes")
+	spec.PrometheusK8S.RetentionSizePercent = nil
+	spec.PrometheusK8S.AutoSizing = nil
+	spec.SnapshotBeforeChange = nil
+	spec.DefaultsProfile = ""
+	spec.Sizing = ""
+
+	// CMO takes the Telemeter token inline, so the one from tokenSecret
+	// replaces the deprecated inline token
+	if ref := spec.TelemeterClient.TokenSecret; ref != nil {
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: t.namespace(), Name: ref.Name}, secret); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, err
+			}
 		}
+		spec.TelemeterClient.Token = string(secret.Data[ref.Key])
+		spec.TelemeterClient.TokenSecret = nil
 	}
-
-	return ctrl.Result{}, nil
+	return spec, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Outside the cluster, e.g. with make run, auto-sizing skips the head series
+	prometheus, err := prometheusClient()
+	if err != nil {
+		mgr.GetLogger().Info("No Prometheus client, auto-sizing from nodes and pods only", "reason", err.Error())
+	}
+	r.prometheus = prometheus
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&monitoringv1beta1.Cluster{}).
+		// Status updates, e.g. the PVC retry count, must not trigger a reconcile
+		For(&monitoringv1.Cluster{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// The User CR can conflict with the Cluster CR, see clusterWarnings
+		Watches(&monitoringv1.User{}, enqueueTarget(clusterTarget{}),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&monitoringv1.UserFragment{}, enqueueTarget(clusterTarget{}),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// Fragments are merged into the Cluster CR, see mergeFragments
+		Watches(&monitoringv1.ClusterFragment{}, enqueueTarget(clusterTarget{}),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// A resized PVC changes the retentionSize derived from its capacity
+		Watches(&corev1.PersistentVolumeClaim{}, enqueueTarget(clusterTarget{}),
+			builder.WithPredicates(pvcCapacityChanged(clusterTarget{}))).
+		// Template variables are read from this ConfigMap, see clusterVariables
+		Watches(&corev1.ConfigMap{}, enqueueTarget(clusterTarget{}),
+			builder.WithPredicates(predicate.NewPredicateFuncs(isVariablesConfigMap))).
 		Complete(r)
 }
@@ -0,0 +1,191 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+// The CRD schema rejects these specs before the controller sees them, so they
+// are checked against a real API server. Objects are only created in dry-run.
+var _ = Describe("CRD validation", func() {
+	create := func(object client.Object) error {
+		return k8sClient.Create(context.Background(), object, client.DryRunAll)
+	}
+	cluster := func(spec monitoringv1.ClusterSpec) *monitoringv1.Cluster {
+		return &monitoringv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster-monitoring-config"}, Spec: spec}
+	}
+	user := func(spec monitoringv1.UserSpec) *monitoringv1.User {
+		return &monitoringv1.User{ObjectMeta: metav1.ObjectMeta{Name: "user-workload-monitoring-config"}, Spec: spec}
+	}
+	negative := int64(-1)
+
+	It("accepts a valid spec", func() {
+		Expect(create(cluster(monitoringv1.ClusterSpec{
+			PrometheusK8S: monitoringv1.PrometheusK8S{
+				CommonPodSettings: monitoringv1.CommonPodSettings{LogLevel: "debug"},
+				Retention:         "15d",
+			},
+		}))).To(Succeed())
+	})
+
+	DescribeTable("rejects an invalid spec",
+		func(object client.Object, field string) {
+			err := create(object)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue(), "create returned %v", err)
+			Expect(err.Error()).To(ContainSubstring(field))
+		},
+		Entry("malformed retention",
+			cluster(monitoringv1.ClusterSpec{PrometheusK8S: monitoringv1.PrometheusK8S{Retention: "banana"}}),
+			"spec.prometheusK8s.retention"),
+		Entry("unknown logLevel",
+			cluster(monitoringv1.ClusterSpec{PrometheusK8S: monitoringv1.PrometheusK8S{
+				CommonPodSettings: monitoringv1.CommonPodSettings{LogLevel: "loud"},
+			}}),
+			"spec.prometheusK8s.logLevel"),
+		Entry("negative cluster limit",
+			cluster(monitoringv1.ClusterSpec{PrometheusK8S: monitoringv1.PrometheusK8S{EnforcedSampleLimit: &negative}}),
+			"spec.prometheusK8s.enforcedSampleLimit"),
+		Entry("negative user limit",
+			user(monitoringv1.UserSpec{Prometheus: monitoringv1.Prometheus{EnforcedLabelLimit: &negative}}),
+			"spec.prometheus.enforcedLabelLimit"),
+		Entry("user retention",
+			user(monitoringv1.UserSpec{ThanosRuler: monitoringv1.ThanosRuler{Retention: "banana"}}),
+			"spec.thanosRuler.retention"),
+	)
+
+	secretKey := func(name string) *corev1.SecretKeySelector {
+		return &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: "tls.crt"}
+	}
+	alertmanagers := func(tlsConfig monitoringv1.TLSConfig) monitoringv1.ClusterSpec {
+		return monitoringv1.ClusterSpec{PrometheusK8S: monitoringv1.PrometheusK8S{
+			AdditionalAlertManagerConfigs: []monitoringv1.AdditionalAlertManagerConfigs{{TLSConfig: &tlsConfig}},
+		}}
+	}
+	remoteWrite := func(remoteWrite monitoringv1.RemoteWriteSpec) monitoringv1.UserSpec {
+		remoteWrite.URL = "https://remote-write.example.com/api/v1/write"
+		return monitoringv1.UserSpec{Prometheus: monitoringv1.Prometheus{RemoteWrite: []monitoringv1.RemoteWriteSpec{remoteWrite}}}
+	}
+
+	// Each x-kubernetes-validations rule accepts the first object and rejects
+	// the second one with its message.
+	DescribeTable("enforces the validation rules",
+		func(valid, invalid client.Object, message string) {
+			Expect(create(valid)).To(Succeed())
+			err := create(invalid)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue(), "create returned %v", err)
+			Expect(err.Error()).To(ContainSubstring(message))
+		},
+		Entry("user Alertmanager config with the Alertmanager disabled",
+			cluster(monitoringv1.ClusterSpec{AlertmanagerMain: monitoringv1.AlertmanagerMain{EnableUserAlertmanagerConfig: true}}),
+			cluster(monitoringv1.ClusterSpec{AlertmanagerMain: monitoringv1.AlertmanagerMain{Enabled: BoolPointer(false), EnableUserAlertmanagerConfig: true}}),
+			"enableUserAlertmanagerConfig requires the Alertmanager to be enabled"),
+		Entry("Alertmanager cert without key",
+			cluster(alertmanagers(monitoringv1.TLSConfig{Cert: secretKey("alertmanager-tls"), Key: secretKey("alertmanager-tls")})),
+			cluster(alertmanagers(monitoringv1.TLSConfig{Cert: secretKey("alertmanager-tls")})),
+			"cert and key must be set together"),
+		Entry("reserved cluster external labels",
+			cluster(monitoringv1.ClusterSpec{PrometheusK8S: monitoringv1.PrometheusK8S{ExternalLabels: map[string]string{"region": "eu"}}}),
+			cluster(monitoringv1.ClusterSpec{PrometheusK8S: monitoringv1.PrometheusK8S{ExternalLabels: map[string]string{"prometheus_replica": "a"}}}),
+			"prometheus and prometheus_replica are reserved external labels"),
+		Entry("reserved user external labels",
+			user(monitoringv1.UserSpec{Prometheus: monitoringv1.Prometheus{ExternalLabels: map[string]string{"region": "eu"}}}),
+			user(monitoringv1.UserSpec{Prometheus: monitoringv1.Prometheus{ExternalLabels: map[string]string{"prometheus": "a"}}}),
+			"prometheus and prometheus_replica are reserved external labels"),
+		Entry("cluster external label names",
+			cluster(monitoringv1.ClusterSpec{PrometheusK8S: monitoringv1.PrometheusK8S{ExternalLabels: map[string]string{"_cluster": "a"}}}),
+			cluster(monitoringv1.ClusterSpec{PrometheusK8S: monitoringv1.PrometheusK8S{ExternalLabels: map[string]string{"cluster-name": "a"}}}),
+			"external label names must match"),
+		Entry("user external label names",
+			user(monitoringv1.UserSpec{Prometheus: monitoringv1.Prometheus{ExternalLabels: map[string]string{"team_1": "a"}}}),
+			user(monitoringv1.UserSpec{Prometheus: monitoringv1.Prometheus{ExternalLabels: map[string]string{"1team": "a"}}}),
+			"external label names must match"),
+		Entry("cluster query log under /dev",
+			cluster(monitoringv1.ClusterSpec{PrometheusK8S: monitoringv1.PrometheusK8S{QueryLogFile: "/dev/stdout"}}),
+			cluster(monitoringv1.ClusterSpec{PrometheusK8S: monitoringv1.PrometheusK8S{QueryLogFile: "/dev/sda"}}),
+			"only /dev/stdout and /dev/stderr are allowed under /dev"),
+		Entry("user query log under /dev",
+			user(monitoringv1.UserSpec{Prometheus: monitoringv1.Prometheus{QueryLogFile: "/tmp/query.log"}}),
+			user(monitoringv1.UserSpec{Prometheus: monitoringv1.Prometheus{QueryLogFile: "/dev/null"}}),
+			"only /dev/stdout and /dev/stderr are allowed under /dev"),
+		Entry("cluster scrapeInterval range",
+			cluster(monitoringv1.ClusterSpec{PrometheusK8S: monitoringv1.PrometheusK8S{ScrapeInterval: "5m"}}),
+			cluster(monitoringv1.ClusterSpec{PrometheusK8S: monitoringv1.PrometheusK8S{ScrapeInterval: "1s"}}),
+			"must be between 5s and 5m"),
+		Entry("user scrapeInterval range",
+			user(monitoringv1.UserSpec{Prometheus: monitoringv1.Prometheus{ScrapeInterval: "5s"}}),
+			user(monitoringv1.UserSpec{Prometheus: monitoringv1.Prometheus{ScrapeInterval: "1d"}}),
+			"must be between 5s and 5m"),
+		Entry("user evaluationInterval range",
+			user(monitoringv1.UserSpec{Prometheus: monitoringv1.Prometheus{EvaluationInterval: "1m30s"}}),
+			user(monitoringv1.UserSpec{Prometheus: monitoringv1.Prometheus{EvaluationInterval: "10m"}}),
+			"must be between 5s and 5m"),
+		Entry("remote write authorization and basicAuth",
+			user(remoteWrite(monitoringv1.RemoteWriteSpec{Authorization: &monitoringv1.SafeAuthorization{Credentials: secretKey("remote-write")}})),
+			user(remoteWrite(monitoringv1.RemoteWriteSpec{
+				Authorization: &monitoringv1.SafeAuthorization{Credentials: secretKey("remote-write")},
+				BasicAuth:     &monitoringv1.BasicAuth{Username: *secretKey("remote-write"), Password: *secretKey("remote-write")},
+			})),
+			"authorization and basicAuth are mutually exclusive"),
+		Entry("remote write shards",
+			user(remoteWrite(monitoringv1.RemoteWriteSpec{QueueConfig: &monitoringv1.QueueConfig{MinShards: 2, MaxShards: 2}})),
+			user(remoteWrite(monitoringv1.RemoteWriteSpec{QueueConfig: &monitoringv1.QueueConfig{MinShards: 3, MaxShards: 2}})),
+			"minShards must not exceed maxShards"),
+		Entry("remote write cert without keySecret",
+			user(remoteWrite(monitoringv1.RemoteWriteSpec{TLSConfig: &monitoringv1.SafeTLSConfig{
+				Cert:      monitoringv1.SecretOrConfigMap{Secret: secretKey("remote-write-tls")},
+				KeySecret: secretKey("remote-write-tls"),
+			}})),
+			user(remoteWrite(monitoringv1.RemoteWriteSpec{TLSConfig: &monitoringv1.SafeTLSConfig{
+				Cert: monitoringv1.SecretOrConfigMap{Secret: secretKey("remote-write-tls")},
+			}})),
+			"cert and keySecret must be set together"),
+		Entry("remote write CA from a Secret and a ConfigMap",
+			user(remoteWrite(monitoringv1.RemoteWriteSpec{TLSConfig: &monitoringv1.SafeTLSConfig{
+				CA: monitoringv1.SecretOrConfigMap{ConfigMap: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "remote-write-ca"}, Key: "ca.crt"}},
+			}})),
+			user(remoteWrite(monitoringv1.RemoteWriteSpec{TLSConfig: &monitoringv1.SafeTLSConfig{
+				CA: monitoringv1.SecretOrConfigMap{
+					Secret:    secretKey("remote-write-ca"),
+					ConfigMap: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "remote-write-ca"}, Key: "ca.crt"},
+				},
+			}})),
+			"secret and configMap are mutually exclusive"),
+		Entry("hashmod modulus",
+			user(remoteWrite(monitoringv1.RemoteWriteSpec{WriteRelabelConfigs: []monitoringv1.RelabelConfig{{
+				SourceLabels: []string{"instance"}, TargetLabel: "shard", Action: "hashmod", Modulus: 4,
+			}}})),
+			user(remoteWrite(monitoringv1.RemoteWriteSpec{WriteRelabelConfigs: []monitoringv1.RelabelConfig{{
+				SourceLabels: []string{"instance"}, TargetLabel: "shard", Action: "hashmod",
+			}}})),
+			"modulus is required by the hashmod action"),
+		Entry("Thanos Ruler evaluationInterval",
+			user(monitoringv1.UserSpec{ThanosRuler: monitoringv1.ThanosRuler{EvaluationInterval: "15m"}}),
+			user(monitoringv1.UserSpec{ThanosRuler: monitoringv1.ThanosRuler{EvaluationInterval: "0s"}}),
+			"must be a positive duration"),
+	)
+})
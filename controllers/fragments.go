@@ -0,0 +1,284 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+const conditionMerged = "Merged"
+
+// fragment is a ClusterFragment or UserFragment, reduced to what merging needs.
+type fragment struct {
+	object     client.Object
+	kind       string
+	priority   int32
+	components []string
+	// config points to the spec the fragment merges into the CR's.
+	config     interface{}
+	conditions *[]metav1.Condition
+}
+
+// rejection is why a fragment was left out of the merge.
+type rejection struct {
+	reason  string
+	message string
+}
+
+// leaf is a value a fragment sets, at the path of map keys leading to it.
+// Slices are leaves, so a list is always set as a whole.
+type leaf struct {
+	path  []string
+	value interface{}
+}
+
+// mergeFragments merges fragments into spec, a pointer to the spec of a
+// monitoring CR. Fragments are applied from the lowest to the highest priority,
+// then by name, so a field set by several fragments takes the value of the
+// highest priority one. A fragment is rejected if it sets a section outside its
+// components, or if a fragment of the same priority sets one of its fields to a
+// different value; the order of equal priority fragments then never matters.
+//
+// It returns the fragment owning each field it set, sorted by path, and the
+// rejected fragments by name. spec is left unchanged if no fragment applies.
+func mergeFragments(spec interface{}, fragments []fragment) ([]monitoringv1.FieldOwner, map[string]rejection, error) {
+	fragments = slices.Clone(fragments)
+	sort.SliceStable(fragments, func(i, j int) bool {
+		if fragments[i].priority != fragments[j].priority {
+			return fragments[i].priority < fragments[j].priority
+		}
+		return fragments[i].object.GetName() < fragments[j].object.GetName()
+	})
+
+	rejected := make(map[string]rejection)
+	leavesOf := make([][]leaf, len(fragments))
+	for i, f := range fragments {
+		value, err := renderValue(reflect.ValueOf(f.config))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s %s: %w", f.kind, f.object.GetName(), err)
+		}
+		config, _ := value.(map[string]interface{})
+		for _, key := range sortedKeys(config) {
+			if !slices.Contains(f.components, key) {
+				rejected[f.object.GetName()] = rejection{
+					reason:  "OutsideComponents",
+					message: fmt.Sprintf("sets %s, which is not one of its components", key),
+				}
+				break
+			}
+		}
+		leavesOf[i] = leaves(nil, config, nil)
+	}
+
+	// Fragments are sorted by priority, so equal priorities are adjacent.
+	// Fragments already rejected are not merged, so they cannot conflict.
+	outside := make(map[string]bool, len(rejected))
+	for name := range rejected {
+		outside[name] = true
+	}
+	for i := range fragments {
+		for j := i + 1; j < len(fragments) && fragments[j].priority == fragments[i].priority; j++ {
+			if outside[fragments[i].object.GetName()] || outside[fragments[j].object.GetName()] {
+				continue
+			}
+			path, ok := conflict(leavesOf[i], leavesOf[j])
+			if !ok {
+				continue
+			}
+			for _, pair := range [][2]fragment{{fragments[i], fragments[j]}, {fragments[j], fragments[i]}} {
+				if _, ok := rejected[pair[0].object.GetName()]; ok {
+					continue
+				}
+				rejected[pair[0].object.GetName()] = rejection{
+					reason:  "Conflict",
+					message: fmt.Sprintf("sets %s to a different value than %s %s of the same priority", path, pair[1].kind, pair[1].object.GetName()),
+				}
+			}
+		}
+	}
+
+	base, err := renderValue(reflect.ValueOf(spec))
+	if err != nil {
+		return nil, nil, err
+	}
+	tree, _ := base.(map[string]interface{})
+	if tree == nil {
+		tree = make(map[string]interface{})
+	}
+	owners := make(map[string]string)
+	applied := false
+	for i, f := range fragments {
+		if _, ok := rejected[f.object.GetName()]; ok {
+			continue
+		}
+		applied = true
+		for _, l := range leavesOf[i] {
+			if setLeaf(tree, l) {
+				owners[strings.Join(l.path, ".")] = f.object.GetName()
+			}
+		}
+	}
+	if !applied {
+		return nil, rejected, nil
+	}
+
+	// The tree follows the json tags, so it decodes back into the typed spec
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return nil, nil, err
+	}
+	merged := reflect.New(reflect.TypeOf(spec).Elem())
+	if err := json.Unmarshal(data, merged.Interface()); err != nil {
+		return nil, nil, err
+	}
+	reflect.ValueOf(spec).Elem().Set(merged.Elem())
+
+	fieldOwners := make([]monitoringv1.FieldOwner, 0, len(owners))
+	for _, path := range sortedKeys(owners) {
+		fieldOwners = append(fieldOwners, monitoringv1.FieldOwner{Path: path, Fragment: owners[path]})
+	}
+	return fieldOwners, rejected, nil
+}
+
+// leaves appends the leaves of tree below prefix to out, in key order. An
+// empty section is a leaf too, as setting it enables a component.
+func leaves(prefix []string, tree interface{}, out []leaf) []leaf {
+	section, ok := tree.(map[string]interface{})
+	if !ok || (len(section) == 0 && prefix != nil) {
+		return append(out, leaf{path: slices.Clone(prefix), value: tree})
+	}
+	for _, key := range sortedKeys(section) {
+		out = leaves(append(prefix[:len(prefix):len(prefix)], key), section[key], out)
+	}
+	return out
+}
+
+// conflict returns the first path two fragments set to different values.
+func conflict(a, b []leaf) (string, bool) {
+	values := make(map[string]interface{}, len(a))
+	for _, l := range a {
+		values[strings.Join(l.path, ".")] = l.value
+	}
+	for _, l := range b {
+		path := strings.Join(l.path, ".")
+		if value, ok := values[path]; ok && !equality.Semantic.DeepEqual(value, l.value) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// setLeaf sets l in tree, creating the sections leading to it. An empty
+// section does not replace a set one. It reports whether tree was changed.
+func setLeaf(tree map[string]interface{}, l leaf) bool {
+	for _, key := range l.path[:len(l.path)-1] {
+		section, ok := tree[key].(map[string]interface{})
+		if !ok {
+			section = make(map[string]interface{})
+			tree[key] = section
+		}
+		tree = section
+	}
+	key := l.path[len(l.path)-1]
+	if section, ok := l.value.(map[string]interface{}); ok && len(section) == 0 {
+		if _, ok := tree[key]; ok {
+			return false
+		}
+	}
+	tree[key] = l.value
+	return true
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fragmentWarnings describes the rejected fragments, in name order.
+func fragmentWarnings(fragments []fragment, rejected map[string]rejection) []string {
+	var warnings []string
+	for _, f := range fragments {
+		if r, ok := rejected[f.object.GetName()]; ok {
+			warnings = append(warnings, fmt.Sprintf("%s %s is not merged as it %s", f.kind, f.object.GetName(), r.message))
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// updateFragmentStatus records in each fragment whether it was merged.
+func updateFragmentStatus(ctx context.Context, c client.Client, fragments []fragment, rejected map[string]rejection) error {
+	for _, f := range fragments {
+		original := f.object.DeepCopyObject().(client.Object)
+		condition := metav1.Condition{
+			Type:               conditionMerged,
+			Status:             metav1.ConditionTrue,
+			Reason:             "Merged",
+			ObservedGeneration: f.object.GetGeneration(),
+		}
+		if r, ok := rejected[f.object.GetName()]; ok {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = r.reason
+			condition.Message = r.message
+		}
+		meta.SetStatusCondition(f.conditions, condition)
+		if equality.Semantic.DeepEqual(original, f.object) {
+			continue
+		}
+		if err := c.Status().Patch(ctx, f.object, client.MergeFrom(original)); err != nil {
+			log.FromContext(ctx).Error(err, "Unable to update fragment status", "fragment", f.object.GetName())
+			return err
+		}
+	}
+	return nil
+}
+
+// getMerged gets the CR of target into object with its fragments merged into
+// the spec, as the controller renders it. Errors getting the CR are returned
+// as is, so callers can check for NotFound.
+func getMerged(ctx context.Context, c client.Reader, target monitoringTarget, object client.Object) error {
+	if err := c.Get(ctx, client.ObjectKey{Name: target.configMapName()}, object); err != nil {
+		return err
+	}
+	fragments, err := target.fragments(ctx, c)
+	if err != nil {
+		return err
+	}
+	if _, _, err := mergeFragments(target.spec(object), fragments); err != nil {
+		return err
+	}
+	expandSizing(target.sizing(object))
+	return nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+func clusterFragment(name string, priority int32, components []string, config monitoringv1.ClusterSpec) *monitoringv1.ClusterFragment {
+	return &monitoringv1.ClusterFragment{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: monitoringv1.ClusterFragmentSpec{
+			Priority:   priority,
+			Components: components,
+			Config:     config,
+		},
+	}
+}
+
+func TestMergeFragments(t *testing.T) {
+	prometheus := []string{"prometheusK8s"}
+	objects := []*monitoringv1.ClusterFragment{
+		// Listed out of order, as the merge sorts them
+		clusterFragment("high", 2, prometheus, monitoringv1.ClusterSpec{
+			PrometheusK8S: monitoringv1.PrometheusK8S{Retention: "30d"},
+		}),
+		clusterFragment("low", 1, prometheus, monitoringv1.ClusterSpec{
+			PrometheusK8S: monitoringv1.PrometheusK8S{
+				CommonPodSettings: monitoringv1.CommonPodSettings{LogLevel: "debug"},
+				Retention:         "15d",
+			},
+		}),
+		// Agrees with high, so both apply and the later name owns the field
+		clusterFragment("high-too", 2, prometheus, monitoringv1.ClusterSpec{
+			PrometheusK8S: monitoringv1.PrometheusK8S{Retention: "30d"},
+		}),
+		clusterFragment("outside", 3, prometheus, monitoringv1.ClusterSpec{
+			AlertmanagerMain: monitoringv1.AlertmanagerMain{Enabled: BoolPointer(false)},
+		}),
+		clusterFragment("conflict-a", 4, prometheus, monitoringv1.ClusterSpec{
+			PrometheusK8S: monitoringv1.PrometheusK8S{ScrapeInterval: "30s"},
+		}),
+		clusterFragment("conflict-b", 4, prometheus, monitoringv1.ClusterSpec{
+			PrometheusK8S: monitoringv1.PrometheusK8S{ScrapeInterval: "1m"},
+		}),
+	}
+	var fragments []fragment
+	for _, object := range objects {
+		fragments = append(fragments, fragment{
+			object:     object,
+			kind:       "ClusterFragment",
+			priority:   object.Spec.Priority,
+			components: object.Spec.Components,
+			config:     &object.Spec.Config,
+		})
+	}
+
+	spec := &monitoringv1.ClusterSpec{
+		EnableUserWorkload: true,
+		PrometheusK8S:      monitoringv1.PrometheusK8S{Retention: "7d", ScrapeInterval: "15s"},
+	}
+	owners, rejected, err := mergeFragments(spec, fragments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &monitoringv1.ClusterSpec{
+		EnableUserWorkload: true,
+		PrometheusK8S: monitoringv1.PrometheusK8S{
+			CommonPodSettings: monitoringv1.CommonPodSettings{LogLevel: "debug"},
+			Retention:         "30d",
+			ScrapeInterval:    "15s",
+		},
+	}
+	if !reflect.DeepEqual(spec, want) {
+		t.Errorf("merged spec = %+v, want %+v", spec, want)
+	}
+	wantOwners := []monitoringv1.FieldOwner{
+		{Path: "prometheusK8s.logLevel", Fragment: "low"},
+		{Path: "prometheusK8s.retention", Fragment: "high-too"},
+	}
+	if !reflect.DeepEqual(owners, wantOwners) {
+		t.Errorf("owners = %+v, want %+v", owners, wantOwners)
+	}
+	wantReasons := map[string]string{"outside": "OutsideComponents", "conflict-a": "Conflict", "conflict-b": "Conflict"}
+	if len(rejected) != len(wantReasons) {
+		t.Errorf("rejected = %+v, want %v", rejected, wantReasons)
+	}
+	for name, reason := range wantReasons {
+		if rejected[name].reason != reason {
+			t.Errorf("rejected[%s] = %+v, want %s", name, rejected[name], reason)
+		}
+	}
+	if !strings.Contains(rejected["conflict-a"].message, "prometheusK8s.scrapeInterval") {
+		t.Errorf("conflict message = %q, want the conflicting path", rejected["conflict-a"].message)
+	}
+}
+
+func TestMergeFragmentsNone(t *testing.T) {
+	spec := &monitoringv1.UserSpec{Prometheus: monitoringv1.Prometheus{Retention: "7d"}}
+	want := spec.DeepCopy()
+	owners, rejected, err := mergeFragments(spec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owners != nil || len(rejected) != 0 || !reflect.DeepEqual(spec, want) {
+		t.Errorf("mergeFragments(nil) = %+v, %+v, spec %+v; want no change", owners, rejected, spec)
+	}
+}
+
+func TestMonitoringReconcilerMergesFragments(t *testing.T) {
+	cluster := &monitoringv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-monitoring-config"},
+		Spec: monitoringv1.ClusterSpec{
+			PrometheusK8S: monitoringv1.PrometheusK8S{Retention: "7d"},
+		},
+	}
+	merged := clusterFragment("storage", 10, []string{"prometheusK8s"}, monitoringv1.ClusterSpec{
+		PrometheusK8S: monitoringv1.PrometheusK8S{Retention: "15d"},
+	})
+	outside := clusterFragment("alerting", 10, []string{"prometheusK8s"}, monitoringv1.ClusterSpec{
+		AlertmanagerMain: monitoringv1.AlertmanagerMain{Enabled: BoolPointer(false)},
+	})
+	c := newFakeClient(t, cluster, merged, outside)
+	target := clusterTarget{}
+	reconcileTarget(t, c, target)
+
+	if rendered := renderedConfig(t, c, target); !strings.Contains(rendered, "retention: 15d") || strings.Contains(rendered, "alertmanagerMain") {
+		t.Errorf("config.yaml = %q, want the storage fragment only", rendered)
+	}
+
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(cluster), cluster); err != nil {
+		t.Fatal(err)
+	}
+	if cluster.Spec.PrometheusK8S.Retention != "7d" {
+		t.Errorf("spec.prometheusK8s.retention = %s, merged into the stored CR", cluster.Spec.PrometheusK8S.Retention)
+	}
+	wantOwners := []monitoringv1.FieldOwner{{Path: "prometheusK8s.retention", Fragment: "storage"}}
+	if !reflect.DeepEqual(cluster.Status.FieldOwners, wantOwners) {
+		t.Errorf("fieldOwners = %+v, want %+v", cluster.Status.FieldOwners, wantOwners)
+	}
+	if warning := meta.FindStatusCondition(cluster.Status.Conditions, conditionWarning); warning == nil || !strings.Contains(warning.Message, "ClusterFragment alerting") {
+		t.Errorf("Warning condition = %+v, want the rejected fragment", warning)
+	}
+
+	for name, want := range map[string]metav1.ConditionStatus{"storage": metav1.ConditionTrue, "alerting": metav1.ConditionFalse} {
+		fragment := &monitoringv1.ClusterFragment{}
+		if err := c.Get(context.Background(), client.ObjectKey{Name: name}, fragment); err != nil {
+			t.Fatal(err)
+		}
+		if condition := meta.FindStatusCondition(fragment.Status.Conditions, conditionMerged); condition == nil || condition.Status != want {
+			t.Errorf("%s: Merged condition = %+v, want %s", name, condition, want)
+		}
+	}
+}
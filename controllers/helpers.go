@@ -19,60 +19,232 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"strings"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
 )
 
 func BoolPointer(b bool) *bool {
 	return &b
 }
 
-func removeMetadata(data map[string]interface{}) {
-	for key, value := range data {
-		if key == "metadata" {
-			delete(data, "metadata")
-		} else if nestedMap, ok := value.(map[string]interface{}); ok {
-			removeMetadata(nestedMap)
+// pvcResult is the outcome of reconciling the PVCs of one StatefulSet.
+type pvcResult struct {
+	// failed lists the PVCs that could not be expanded.
+	failed []string
+	// snapshots lists the VolumeSnapshots kept for the PVCs.
+	snapshots []monitoringv1.PVCSnapshot
+	// waiting is set while an expansion waits for its snapshot to be ready.
+	waiting bool
+}
+
+// statefulSetPVCs returns the existing PVCs of the given StatefulSet in the
+// namespace, or none if the StatefulSet does not exist.
+func statefulSetPVCs(ctx context.Context, c client.Reader, namespace string, statefulSetName string) ([]*corev1.PersistentVolumeClaim, error) {
+	statefulSet := &appsv1.StatefulSet{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: statefulSetName}, statefulSet); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get StatefulSet %s/%s: %w", namespace, statefulSetName, err)
+	}
+
+	pvcNames := statefulSetPVCNames(statefulSet)
+	listOptions := []client.ListOption{client.InNamespace(namespace)}
+	if statefulSet.Spec.Selector != nil {
+		listOptions = append(listOptions, client.MatchingLabels(statefulSet.Spec.Selector.MatchLabels))
+	}
+
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	if err := c.List(ctx, pvcList, listOptions...); err != nil {
+		return nil, fmt.Errorf("unable to list PVCs in namespace %s: %w", namespace, err)
+	}
+	var pvcs []*corev1.PersistentVolumeClaim
+	for i := range pvcList.Items {
+		if pvcNames[pvcList.Items[i].Name] {
+			pvcs = append(pvcs, &pvcList.Items[i])
 		}
 	}
+	return pvcs, nil
 }
 
-// reconcilePVCSize checks if PVCs matching the given name prefix in the namespace
+// pvcCapacity returns the smallest capacity among the PVCs of the given
+// StatefulSet in the namespace, or nil if no PVC exists yet. It only reads the
+// PVCs, which the PVCReconciler expands.
+func pvcCapacity(ctx context.Context, c client.Reader, namespace string, statefulSetName string) (*resource.Quantity, error) {
+	pvcs, err := statefulSetPVCs(ctx, c, namespace, statefulSetName)
+	if err != nil {
+		return nil, err
+	}
+	var capacity *resource.Quantity
+	for _, pvc := range pvcs {
+		size := pvcSize(pvc)
+		if capacity == nil || size.Cmp(*capacity) < 0 {
+			capacity = &size
+		}
+	}
+	return capacity, nil
+}
+
+// reconcilePVCSize checks if the PVCs of the given StatefulSet in the namespace
 // have the correct size, and if not, expands them to match the desired size
-// from the volumeClaimTemplate.
-func reconcilePVCSize(ctx context.Context, c client.Client, namespace string, pvcPrefix string, vct *corev1.PersistentVolumeClaimTemplate) error {
+// from the volumeClaimTemplate, if any. A PVC that cannot be expanded does not
+// stop the others; the failures are returned as one aggregated error. With
+// snapshot set, each PVC is only expanded once a VolumeSnapshot of it is ready.
+func reconcilePVCSize(ctx context.Context, c client.Client, namespace string, statefulSetName string, vct *corev1.PersistentVolumeClaimTemplate, snapshot *monitoringv1.SnapshotBeforeChange) (pvcResult, error) {
 	log := log.FromContext(ctx)
 
+	var result pvcResult
+	if vct == nil {
+		return result, nil
+	}
 	desiredSize, ok := vct.Spec.Resources.Requests[corev1.ResourceStorage]
 	if !ok {
-		return nil
+		return result, nil
 	}
 
-	pvcList := &corev1.PersistentVolumeClaimList{}
-	if err := c.List(ctx, pvcList, client.InNamespace(namespace)); err != nil {
-		return fmt.Errorf("unable to list PVCs in namespace %s: %w", namespace, err)
+	pvcs, err := statefulSetPVCs(ctx, c, namespace, statefulSetName)
+	if err != nil {
+		return result, err
 	}
 
-	for i := range pvcList.Items {
-		pvc := &pvcList.Items[i]
-		if !strings.HasPrefix(pvc.Name, pvcPrefix) {
-			continue
-		}
-
+	var errs []error
+	for _, pvc := range pvcs {
 		currentSize := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
-		if currentSize.Cmp(desiredSize) < 0 {
+		expand := currentSize.Cmp(desiredSize) < 0
+		if expand && snapshot != nil {
+			ready, err := snapshotPVC(ctx, c, statefulSetName, pvc, desiredSize, snapshot)
+			if err != nil {
+				errs = append(errs, err)
+				result.failed = append(result.failed, pvc.Name)
+				continue
+			}
+			if !ready {
+				log.Info("Waiting for VolumeSnapshot before expanding PVC", "pvc", pvc.Name, "namespace", namespace)
+				result.waiting = true
+				expand = false
+			}
+		}
+		if expand {
 			log.V(1).Info("Expanding PVC", "pvc", pvc.Name, "namespace", namespace,
 				"currentSize", currentSize.String(), "desiredSize", desiredSize.String())
 			patch := client.MergeFrom(pvc.DeepCopy())
 			pvc.Spec.Resources.Requests[corev1.ResourceStorage] = desiredSize
 			if err := c.Patch(ctx, pvc, patch); err != nil {
-				return fmt.Errorf("unable to expand PVC %s/%s: %w", namespace, pvc.Name, err)
+				errs = append(errs, fmt.Errorf("unable to expand PVC %s/%s: %w", namespace, pvc.Name, err))
+				result.failed = append(result.failed, pvc.Name)
 			}
 		}
 	}
 
-	return nil
+	if snapshot != nil {
+		snapshots, err := pruneSnapshots(ctx, c, namespace, statefulSetName, snapshot.Retention)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		result.snapshots = snapshots
+	}
+
+	return result, utilerrors.NewAggregate(errs)
+}
+
+// setPVCStatus records the outcome of reconciling the PVCs of component,
+// counting consecutive failures and dropping the entry once it succeeds.
+func setPVCStatus(statuses []monitoringv1.PVCStatus, component string, failed []string, err error) []monitoringv1.PVCStatus {
+	result := make([]monitoringv1.PVCStatus, 0, len(statuses)+1)
+	found := false
+	for _, status := range statuses {
+		if status.Component != component {
+			result = append(result, status)
+			continue
+		}
+		found = true
+		if err != nil {
+			status.FailedPVCs = failed
+			status.Message = err.Error()
+			status.RetryCount++
+			result = append(result, status)
+		}
+	}
+	if err != nil && !found {
+		result = append(result, monitoringv1.PVCStatus{
+			Component:  component,
+			FailedPVCs: failed,
+			Message:    err.Error(),
+			RetryCount: 1,
+		})
+	}
+	return result
+}
+
+// statefulSetPVCNames returns the names of the PVCs the StatefulSet controller
+// creates for each replica, <volumeClaimTemplate>-<statefulSet>-<ordinal>.
+func statefulSetPVCNames(statefulSet *appsv1.StatefulSet) map[string]bool {
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	start := int32(0)
+	if statefulSet.Spec.Ordinals != nil {
+		start = statefulSet.Spec.Ordinals.Start
+	}
+
+	names := make(map[string]bool)
+	for _, template := range statefulSet.Spec.VolumeClaimTemplates {
+		for ordinal := start; ordinal < start+replicas; ordinal++ {
+			names[fmt.Sprintf("%s-%s-%d", template.Name, statefulSet.Name, ordinal)] = true
+		}
+	}
+	return names
+}
+
+// pvcSize returns the larger of the requested and the provisioned storage of
+// the PVC, so an expansion that is still in progress already counts.
+func pvcSize(pvc *corev1.PersistentVolumeClaim) resource.Quantity {
+	size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if provisioned, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok && provisioned.Cmp(size) > 0 {
+		return provisioned
+	}
+	return size
+}
+
+// retentionSize derives the Prometheus retentionSize as percent of the PVC
+// capacity, falling back to the volumeClaimTemplate request when no PVC exists
+// yet. The value is rendered in base-2 units, which Prometheus expects, and
+// rounded down so it stays within the percentage. Nothing is derived from an
+// empty volume, as a retentionSize of 0 disables the limit.
+func retentionSize(percent *int32, capacity *resource.Quantity, vct *corev1.PersistentVolumeClaimTemplate) monitoringv1.ByteSize {
+	if percent == nil {
+		return ""
+	}
+	if capacity == nil {
+		if vct == nil {
+			return ""
+		}
+		templateSize, ok := vct.Spec.Resources.Requests[corev1.ResourceStorage]
+		if !ok {
+			return ""
+		}
+		capacity = &templateSize
+	}
+
+	bytes := capacity.Value() * int64(*percent) / 100
+	switch {
+	case bytes <= 0:
+		return ""
+	case bytes%(1<<30) == 0:
+		return monitoringv1.ByteSize(fmt.Sprintf("%dGiB", bytes>>30))
+	case bytes >= 1<<20:
+		return monitoringv1.ByteSize(fmt.Sprintf("%dMiB", bytes>>20))
+	case bytes >= 1<<10:
+		return monitoringv1.ByteSize(fmt.Sprintf("%dKiB", bytes>>10))
+	}
+	return monitoringv1.ByteSize(fmt.Sprintf("%dB", bytes))
 }
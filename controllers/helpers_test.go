@@ -0,0 +1,169 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+func newFakeClient(t *testing.T, objects ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := monitoringv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&monitoringv1.Cluster{}, &monitoringv1.User{}, &monitoringv1.UserWorkloadRequest{},
+			&monitoringv1.ClusterFragment{}, &monitoringv1.UserFragment{}).
+		Build()
+}
+
+func reconcileTarget(t *testing.T, c client.Client, target monitoringTarget) ctrl.Result {
+	t.Helper()
+	r := &monitoringReconciler{Client: c, target: target}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKey{Name: target.configMapName()}})
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	return result
+}
+
+// renderedConfig returns the config.yaml rendered into the ConfigMap of target.
+func renderedConfig(t *testing.T, c client.Client, target monitoringTarget) string {
+	t.Helper()
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: target.namespace(), Name: target.configMapName()}, configMap); err != nil {
+		t.Fatal(err)
+	}
+	return configMap.Data["config.yaml"]
+}
+
+func stringPointer(s string) *string {
+	return &s
+}
+
+func int64Pointer(i int64) *int64 {
+	return &i
+}
+
+func quantityPointer(s string) *resource.Quantity {
+	q := resource.MustParse(s)
+	return &q
+}
+
+func TestRetentionSize(t *testing.T) {
+	vct := &corev1.PersistentVolumeClaimTemplate{
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("100Gi")},
+			},
+		},
+	}
+	percent := func(p int32) *int32 { return &p }
+	tests := []struct {
+		name     string
+		percent  *int32
+		capacity *resource.Quantity
+		vct      *corev1.PersistentVolumeClaimTemplate
+		want     monitoringv1.ByteSize
+	}{
+		{name: "unset", capacity: quantityPointer("100Gi")},
+		{name: "whole GiB", percent: percent(90), capacity: quantityPointer("100Gi"), want: "90GiB"},
+		{name: "rounded down to MiB", percent: percent(85), capacity: quantityPointer("10Gi"), want: "8704MiB"},
+		{name: "decimal capacity", percent: percent(90), capacity: quantityPointer("20G"), want: "17166MiB"},
+		{name: "small volume", percent: percent(1), capacity: quantityPointer("10Mi"), want: "102KiB"},
+		{name: "tiny volume", percent: percent(1), capacity: quantityPointer("10Ki"), want: "102B"},
+		{name: "empty volume", percent: percent(90), capacity: quantityPointer("0")},
+		{name: "template", percent: percent(50), vct: vct, want: "50GiB"},
+		{name: "capacity over template", percent: percent(50), capacity: quantityPointer("200Gi"), vct: vct, want: "100GiB"},
+		{name: "no capacity", percent: percent(50)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retentionSize(tt.percent, tt.capacity, tt.vct); got != tt.want {
+				t.Errorf("retentionSize = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetPVCStatus(t *testing.T) {
+	alertmanager := monitoringv1.PVCStatus{Component: "alertmanager-main", FailedPVCs: []string{"alertmanager-main-db-alertmanager-main-0"}, Message: "forbidden", RetryCount: 4}
+	prometheus := monitoringv1.PVCStatus{Component: "prometheus-k8s", FailedPVCs: []string{"prometheus-k8s-db-prometheus-k8s-0"}, Message: "quota exceeded", RetryCount: 1}
+	failedPVCs := []string{"prometheus-k8s-db-prometheus-k8s-1"}
+	tests := []struct {
+		name     string
+		statuses []monitoringv1.PVCStatus
+		err      error
+		want     []monitoringv1.PVCStatus
+	}{
+		{
+			name:     "first failure",
+			statuses: []monitoringv1.PVCStatus{alertmanager},
+			err:      errors.New("storage class does not allow expansion"),
+			want: []monitoringv1.PVCStatus{alertmanager, {
+				Component: "prometheus-k8s", FailedPVCs: failedPVCs, Message: "storage class does not allow expansion", RetryCount: 1,
+			}},
+		},
+		{
+			name:     "repeated failure",
+			statuses: []monitoringv1.PVCStatus{prometheus, alertmanager},
+			err:      errors.New("storage class does not allow expansion"),
+			want: []monitoringv1.PVCStatus{{
+				Component: "prometheus-k8s", FailedPVCs: failedPVCs, Message: "storage class does not allow expansion", RetryCount: 2,
+			}, alertmanager},
+		},
+		{
+			name:     "recovered",
+			statuses: []monitoringv1.PVCStatus{alertmanager, prometheus},
+			want:     []monitoringv1.PVCStatus{alertmanager},
+		},
+		{
+			name: "no failures",
+			want: []monitoringv1.PVCStatus{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var failed []string
+			if tt.err != nil {
+				failed = failedPVCs
+			}
+			if got := setPVCStatus(tt.statuses, "prometheus-k8s", failed, tt.err); !equality.Semantic.DeepEqual(got, tt.want) {
+				t.Errorf("setPVCStatus = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
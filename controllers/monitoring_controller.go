@@ -0,0 +1,395 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+// monitoringTarget describes a monitoring CR kind and the CMO ConfigMap it is
+// rendered into. The methods taking an object are only called with objects
+// returned by newObject.
+type monitoringTarget interface {
+	// newObject returns an empty CR of the kind.
+	newObject() client.Object
+	finalizer() string
+	// namespace is where CMO reads the ConfigMap and runs the components.
+	namespace() string
+	// configMapName is both the ConfigMap name and the only accepted CR name.
+	configMapName() string
+	// spec returns a pointer to the spec, which fragments are merged into.
+	spec(object client.Object) interface{}
+	// fragments lists the fragments of the kind.
+	fragments(ctx context.Context, c client.Reader) ([]fragment, error)
+	// requests applies the namespaced tenant requests for the kind to the
+	// merged spec. The outcome is recorded in each request once the spec is
+	// validated and rendered, see updateRequestStatus.
+	requests(ctx context.Context, c client.Reader, object client.Object) (appliedRequests, error)
+	// autoSize fills the resources the spec leaves unset with those sized from
+	// the scale of the cluster, before the sizing preset is expanded. It
+	// returns the status of the auto-sizing, nil if nothing is auto-sized.
+	autoSize(ctx context.Context, c client.Reader, object client.Object) (*monitoringv1.AutoSizingStatus, error)
+	// sizing returns the sizing preset of the spec and the components it
+	// expands into.
+	sizing(object client.Object) (monitoringv1.Sizing, []sizedComponent)
+	// status returns the status fields shared by every kind.
+	status(object client.Object) targetStatus
+	// components lists the StatefulSets whose PVCs are sized from the spec.
+	components(object client.Object) []pvcComponent
+	snapshot(object client.Object) *monitoringv1.SnapshotBeforeChange
+	// validate checks the spec beyond its OpenAPI schema. retry is set when a
+	// referenced object is missing, as it may be created without a spec change.
+	validate(ctx context.Context, c client.Reader, object client.Object) (errs field.ErrorList, retry bool, err error)
+	// warnings lists the valid settings that will not take effect, and may
+	// record details of them in the status.
+	warnings(ctx context.Context, c client.Reader, object client.Object) ([]string, error)
+	// render returns the ConfigMap content for the spec, given the capacities
+	// of the component PVCs by StatefulSet name. It reads the Secrets whose
+	// values CMO expects inline.
+	render(ctx context.Context, c client.Reader, object client.Object, capacities map[string]*resource.Quantity) (interface{}, error)
+}
+
+// targetStatus points into the status of a monitoring CR.
+type targetStatus struct {
+	// pvcStatus and snapshots are only written by the PVCReconciler.
+	pvcStatus   *[]monitoringv1.PVCStatus
+	snapshots   *[]monitoringv1.PVCSnapshot
+	conditions  *[]metav1.Condition
+	fieldOwners *[]monitoringv1.FieldOwner
+	sizing      *[]monitoringv1.ComponentSizing
+	// unresolvedVariables are the template variables of the spec without a
+	// value.
+	unresolvedVariables *[]string
+	// autoSizing is nil for kinds without auto-sizing.
+	autoSizing **monitoringv1.AutoSizingStatus
+}
+
+// pvcComponent is a StatefulSet with the volumeClaimTemplate its PVCs are
+// sized from, which is nil if not set.
+type pvcComponent struct {
+	statefulSet string
+	vct         *corev1.PersistentVolumeClaimTemplate
+}
+
+// monitoringTargets are the monitoring CR kinds the controller reconciles.
+var monitoringTargets = []monitoringTarget{clusterTarget{}, userTarget{}}
+
+// targetForNamespace returns the target rendered into namespace, or nil.
+func targetForNamespace(namespace string) monitoringTarget {
+	for _, target := range monitoringTargets {
+		if target.namespace() == namespace {
+			return target
+		}
+	}
+	return nil
+}
+
+// enqueueTarget maps the events of an object the CR of target depends on to
+// that CR, as each kind has a single CR named after its ConfigMap.
+func enqueueTarget(target monitoringTarget) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: target.configMapName()}}}
+	})
+}
+
+// monitoringReconciler reconciles a monitoring CR into its CMO ConfigMap. The
+// Cluster and User reconcilers only differ in their target.
+type monitoringReconciler struct {
+	client.Client
+	target monitoringTarget
+}
+
+// Reconcile merges the fragments into the CR, expands its template variables,
+// validates it, reads the capacities of the component PVCs, renders the spec
+// into the ConfigMap and records the outcome in the CR's status.
+func (r *monitoringReconciler) Reconcile(reconcilerContext context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(reconcilerContext)
+	log.V(1).Info(req.Name)
+
+	var (
+		finalizer     = r.target.finalizer()
+		namespace     = r.target.namespace()
+		configMapName = r.target.configMapName()
+	)
+
+	// Incept Object
+	monitoring := r.target.newObject()
+	if err := r.Get(reconcilerContext, req.NamespacedName, monitoring); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			log.V(1).Info("Monitoring Config Not Found or No Longer Exists!")
+			return ctrl.Result{}, nil
+		} else {
+			log.Error(err, "Unable to fetch Monitoring Object")
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+	}
+
+	if req.Name != configMapName {
+		log.V(1).Info("Invalid Object Name!")
+		r.Delete(reconcilerContext, monitoring)
+		return ctrl.Result{}, nil
+	}
+
+	// Delete Logic, Create / Update Finalizers, and Garbage Collect LogSink on Object Deletion
+	// https://book.kubebuilder.io/reference/using-finalizers.html
+	if monitoring.GetDeletionTimestamp().IsZero() {
+		// The object is not being deleted, so if it does not have our finalizer,
+		// then lets add the finalizer and update the object. This is equivalent
+		// registering our finalizer.
+		if !controllerutil.ContainsFinalizer(monitoring, finalizer) {
+			// https://sdk.operatorframework.io/docs/building-operators/golang/references/client/#patch
+			patch := client.MergeFrom(monitoring.DeepCopyObject().(client.Object))
+			controllerutil.AddFinalizer(monitoring, finalizer)
+			// https://sdk.operatorframework.io/docs/building-operators/golang/references/client/#patch
+			if err := r.Patch(reconcilerContext, monitoring, patch); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		// The object is being deleted
+		if controllerutil.ContainsFinalizer(monitoring, finalizer) {
+			// our finalizer is present, so lets handle any external dependency
+			log.V(1).Info("Deleting ConfigMap!")
+			configMap := corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      configMapName,
+					Namespace: namespace,
+				},
+			}
+			err := r.Delete(reconcilerContext, &configMap)
+			if err != nil {
+				log.Error(err, "Unable to Delete ConfigMap!")
+				return ctrl.Result{}, err
+			}
+
+			// remove our finalizer from the list and update it.
+			patch := client.MergeFrom(monitoring.DeepCopyObject().(client.Object))
+			controllerutil.RemoveFinalizer(monitoring, finalizer)
+			if err := r.Patch(reconcilerContext, monitoring, patch); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		// Stop reconciliation as the item is being deleted
+		return ctrl.Result{}, nil
+	}
+
+	// Merge the fragments into the spec, so everything below sees the merged spec
+	fragments, err := r.target.fragments(reconcilerContext, r.Client)
+	if err != nil {
+		log.Error(err, "Unable to list fragments")
+		return ctrl.Result{}, err
+	}
+	owners, rejected, err := mergeFragments(r.target.spec(monitoring), fragments)
+	if err != nil {
+		log.Error(err, "Unable to merge fragments")
+		return ctrl.Result{}, err
+	}
+	if err := updateFragmentStatus(reconcilerContext, r.Client, fragments, rejected); err != nil {
+		return ctrl.Result{}, err
+	}
+	requests, err := r.target.requests(reconcilerContext, r.Client, monitoring)
+	if err != nil {
+		log.Error(err, "Unable to apply requests")
+		return ctrl.Result{}, err
+	}
+	// Expand the template variables of the merged spec, only reading their
+	// values if it uses any
+	var unresolved []string
+	var variableErrs field.ErrorList
+	variables := usedVariables(r.target.spec(monitoring))
+	if variables.Len() > 0 {
+		values, err := clusterVariables(reconcilerContext, r.Client)
+		if err != nil {
+			log.Error(err, "Unable to read template variables")
+			return ctrl.Result{}, err
+		}
+		unresolved, variableErrs = expandVariables(r.target.spec(monitoring), values)
+	}
+	autoSizing, err := r.target.autoSize(reconcilerContext, r.Client, monitoring)
+	if err != nil {
+		log.Error(err, "Unable to auto-size Monitoring Object")
+		return ctrl.Result{}, err
+	}
+	sizing := expandSizing(r.target.sizing(monitoring))
+
+	// The status is changed in place and patched against the original at the end
+	original := monitoring.DeepCopyObject().(client.Object)
+	status := r.target.status(monitoring)
+	*status.fieldOwners = owners
+	*status.sizing = sizing
+	*status.unresolvedVariables = unresolved
+	if status.autoSizing != nil {
+		*status.autoSizing = autoSizing
+	}
+
+	// Validate what the CRD schema cannot express, keeping the current ConfigMap if invalid
+	errs, retry, err := r.target.validate(reconcilerContext, r.Client, monitoring)
+	if err != nil {
+		log.Error(err, "Unable to validate Monitoring Object")
+		return ctrl.Result{}, err
+	}
+	// A missing variable may be set without a spec change, like a missing Secret
+	errs = append(variableErrs, errs...)
+	retry = retry || len(unresolved) > 0
+	if len(errs) > 0 {
+		log.Error(errs.ToAggregate(), "Invalid Monitoring Object, not updating ConfigMap")
+		meta.SetStatusCondition(status.conditions, metav1.Condition{
+			Type:               conditionValid,
+			Status:             metav1.ConditionFalse,
+			Reason:             "InvalidSpec",
+			Message:            errs.ToAggregate().Error(),
+			ObservedGeneration: monitoring.GetGeneration(),
+		})
+		// The accepted requests are held back with the rest of the spec
+		if err := updateRequestStatus(reconcilerContext, r.Client, requests, &rejection{
+			reason:  "NotApplied",
+			message: "the request is accepted, but not applied until the invalid spec it is merged into is fixed",
+		}); err != nil {
+			return ctrl.Result{}, err
+		}
+		// Retrying cannot help until the spec changes, unless a missing Secret is created
+		var result ctrl.Result
+		if retry {
+			result.RequeueAfter = secretPollInterval
+		}
+		return result, r.updateStatus(reconcilerContext, original, monitoring)
+	}
+	meta.SetStatusCondition(status.conditions, metav1.Condition{
+		Type:               conditionValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Valid",
+		ObservedGeneration: monitoring.GetGeneration(),
+	})
+
+	warnings, err := r.target.warnings(reconcilerContext, r.Client, monitoring)
+	if err != nil {
+		log.Error(err, "Unable to check Monitoring Object for ignored settings")
+		return ctrl.Result{}, err
+	}
+	warnings = append(warnings, fragmentWarnings(fragments, rejected)...)
+	setWarningCondition(status.conditions, monitoring.GetGeneration(), warnings)
+
+	// The PVCReconciler expands the PVCs and records the outcome in the status,
+	// so their capacities are only read for the retentionSize
+	capacities := make(map[string]*resource.Quantity)
+	for _, component := range r.target.components(monitoring) {
+		capacity, err := pvcCapacity(reconcilerContext, r.Client, namespace, component.statefulSet)
+		if err != nil {
+			log.Error(err, "Unable to read PVC capacities", "statefulSet", component.statefulSet)
+			return ctrl.Result{}, err
+		}
+		capacities[component.statefulSet] = capacity
+	}
+
+	// Render controller-only settings into their ConfigMap equivalents
+	spec, err := r.target.render(reconcilerContext, r.Client, monitoring, capacities)
+	if err != nil {
+		log.Error(err, "Unable to render ConfigMap")
+		return ctrl.Result{}, err
+	}
+
+	configMapData := make(map[string]string)
+	configMapData["config.yaml"], err = renderConfig(spec)
+	if err != nil {
+		log.Error(err, "Unable to Marshal ConfigMap Struct to Yaml!")
+		return ctrl.Result{}, err
+	}
+
+	gvk, err := r.GroupVersionKindFor(monitoring)
+	if err != nil {
+		log.Error(err, "Unable to determine Monitoring Object kind")
+		return ctrl.Result{}, err
+	}
+	var ownerRef = metav1.OwnerReference{
+		APIVersion:         gvk.GroupVersion().String(),
+		Kind:               gvk.Kind,
+		Name:               monitoring.GetName(),
+		UID:                monitoring.GetUID(),
+		Controller:         BoolPointer(true),
+		BlockOwnerDeletion: BoolPointer(true),
+	}
+	ownerReference := []metav1.OwnerReference{ownerRef}
+
+	configMap := corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            configMapName,
+			Namespace:       namespace,
+			OwnerReferences: ownerReference,
+		},
+		Data: configMapData,
+	}
+
+	// https://medium.com/@aneeshputtur/kubernetes-operators-with-external-configmap-b972c9c36bbe
+	err = r.Create(reconcilerContext, &configMap)
+	if err != nil {
+		log.V(1).Info("Update ConfigMap")
+		r.Update(reconcilerContext, &configMap)
+	} else {
+		log.V(1).Info("Create ConfigMap")
+	}
+	if err := updateRequestStatus(reconcilerContext, r.Client, requests, nil); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.updateStatus(reconcilerContext, original, monitoring); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if pollVariables(variables) {
+		return ctrl.Result{RequeueAfter: variablesPollInterval}, nil
+	}
+	if autoSizing != nil {
+		return ctrl.Result{RequeueAfter: autoSizingInterval}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// updateStatus patches the status of monitoring if it differs from original.
+// Only the status is changed after original is copied, so comparing the whole
+// objects compares the statuses.
+func (r *monitoringReconciler) updateStatus(ctx context.Context, original client.Object, monitoring client.Object) error {
+	if equality.Semantic.DeepEqual(original, monitoring) {
+		return nil
+	}
+	if err := r.Status().Patch(ctx, monitoring, client.MergeFrom(original)); err != nil {
+		log.FromContext(ctx).Error(err, "Unable to update Monitoring Object status")
+		return err
+	}
+	return nil
+}
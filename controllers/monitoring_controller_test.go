@@ -0,0 +1,171 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+func TestMonitoringReconcilerRendersConfigMap(t *testing.T) {
+	retention := "retention: 7d"
+	tests := []struct {
+		target   monitoringTarget
+		object   client.Object
+		kind     string
+		rendered string
+	}{
+		{
+			target: clusterTarget{},
+			object: &monitoringv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-monitoring-config"},
+				Spec: monitoringv1.ClusterSpec{
+					PrometheusK8S: monitoringv1.PrometheusK8S{Retention: "7d"},
+				},
+			},
+			kind:     "Cluster",
+			rendered: retention,
+		},
+		{
+			target: userTarget{},
+			object: &monitoringv1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-workload-monitoring-config"},
+				Spec: monitoringv1.UserSpec{
+					Prometheus: monitoringv1.Prometheus{Retention: "7d"},
+				},
+			},
+			kind:     "User",
+			rendered: retention,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			c := newFakeClient(t, tt.object)
+			if result := reconcileTarget(t, c, tt.target); result != (ctrl.Result{}) {
+				t.Errorf("result = %+v, want none", result)
+			}
+
+			configMap := &corev1.ConfigMap{}
+			if err := c.Get(context.Background(), client.ObjectKey{Namespace: tt.target.namespace(), Name: tt.target.configMapName()}, configMap); err != nil {
+				t.Fatalf("ConfigMap not created: %v", err)
+			}
+			if !strings.Contains(configMap.Data["config.yaml"], tt.rendered) {
+				t.Errorf("config.yaml = %q, want it to contain %q", configMap.Data["config.yaml"], tt.rendered)
+			}
+			if len(configMap.OwnerReferences) != 1 || configMap.OwnerReferences[0].Kind != tt.kind {
+				t.Errorf("ownerReferences = %+v, want the %s", configMap.OwnerReferences, tt.kind)
+			}
+
+			monitoring := tt.target.newObject()
+			if err := c.Get(context.Background(), client.ObjectKey{Name: tt.target.configMapName()}, monitoring); err != nil {
+				t.Fatal(err)
+			}
+			if finalizers := monitoring.GetFinalizers(); len(finalizers) != 1 || finalizers[0] != tt.target.finalizer() {
+				t.Errorf("finalizers = %v, want %s", finalizers, tt.target.finalizer())
+			}
+			if !meta.IsStatusConditionTrue(*tt.target.status(monitoring).conditions, conditionValid) {
+				t.Errorf("conditions = %+v, want Valid", *tt.target.status(monitoring).conditions)
+			}
+		})
+	}
+}
+
+func TestMonitoringReconcilerInvalidSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  monitoringTarget
+		object  client.Object
+		requeue bool
+	}{
+		{
+			name:   "interval out of range",
+			target: clusterTarget{},
+			object: &monitoringv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-monitoring-config"},
+				Spec: monitoringv1.ClusterSpec{
+					PrometheusK8S: monitoringv1.PrometheusK8S{ScrapeInterval: "1s"},
+				},
+			},
+		},
+		{
+			name:   "missing secret",
+			target: userTarget{},
+			object: &monitoringv1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-workload-monitoring-config"},
+				Spec: monitoringv1.UserSpec{
+					Alertmanager: monitoringv1.Alertmanager{Secrets: []string{"missing"}},
+				},
+			},
+			requeue: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFakeClient(t, tt.object)
+			result := reconcileTarget(t, c, tt.target)
+			if requeue := result.RequeueAfter == secretPollInterval; requeue != tt.requeue {
+				t.Errorf("result = %+v, want requeue %v", result, tt.requeue)
+			}
+
+			err := c.Get(context.Background(), client.ObjectKey{Namespace: tt.target.namespace(), Name: tt.target.configMapName()}, &corev1.ConfigMap{})
+			if !apierrors.IsNotFound(err) {
+				t.Errorf("ConfigMap rendered from an invalid spec: %v", err)
+			}
+
+			monitoring := tt.target.newObject()
+			if err := c.Get(context.Background(), client.ObjectKey{Name: tt.target.configMapName()}, monitoring); err != nil {
+				t.Fatal(err)
+			}
+			valid := meta.FindStatusCondition(*tt.target.status(monitoring).conditions, conditionValid)
+			if valid == nil || valid.Status != metav1.ConditionFalse || valid.Reason != "InvalidSpec" {
+				t.Errorf("Valid condition = %+v, want False/InvalidSpec", valid)
+			}
+		})
+	}
+}
+
+func TestMonitoringReconcilerDeletesConfigMap(t *testing.T) {
+	for _, target := range monitoringTargets {
+		monitoring := target.newObject()
+		monitoring.SetName(target.configMapName())
+		monitoring.SetFinalizers([]string{target.finalizer()})
+		now := metav1.Now()
+		monitoring.SetDeletionTimestamp(&now)
+		configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: target.namespace(), Name: target.configMapName()}}
+		c := newFakeClient(t, monitoring, configMap)
+
+		reconcileTarget(t, c, target)
+
+		if err := c.Get(context.Background(), client.ObjectKeyFromObject(configMap), &corev1.ConfigMap{}); !apierrors.IsNotFound(err) {
+			t.Errorf("%s: ConfigMap not deleted: %v", target.configMapName(), err)
+		}
+		// Removing the last finalizer lets the fake client delete the CR
+		if err := c.Get(context.Background(), client.ObjectKeyFromObject(monitoring), target.newObject()); !apierrors.IsNotFound(err) {
+			t.Errorf("%s: CR not released: %v", target.configMapName(), err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterVersion and Infrastructure are read as unstructured objects so the
+// controller does not depend on the OpenShift API module.
+var (
+	clusterVersionGVK = schema.GroupVersionKind{Group: "config.openshift.io", Version: "v1", Kind: "ClusterVersion"}
+	infrastructureGVK = schema.GroupVersionKind{Group: "config.openshift.io", Version: "v1", Kind: "Infrastructure"}
+)
+
+// openshiftVersion returns the version the cluster runs, or is updating to,
+// from the ClusterVersion named version. It returns nil if the version is not
+// known, e.g. when the controller does not run on OpenShift.
+func openshiftVersion(ctx context.Context, c client.Reader) (*version.Version, error) {
+	clusterVersion := &unstructured.Unstructured{}
+	clusterVersion.SetGroupVersionKind(clusterVersionGVK)
+	if err := c.Get(ctx, client.ObjectKey{Name: "version"}, clusterVersion); err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get ClusterVersion: %w", err)
+	}
+
+	desired, _, _ := unstructured.NestedString(clusterVersion.Object, "status", "desired", "version")
+	if desired == "" {
+		return nil, nil
+	}
+	parsed, err := version.ParseGeneric(desired)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse ClusterVersion %q: %w", desired, err)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+// monitoringStatefulSets lists, per namespace, the StatefulSets whose PVCs are
+// sized from a volumeClaimTemplate in the Cluster or User CR.
+var monitoringStatefulSets = func() map[string][]string {
+	statefulSets := make(map[string][]string)
+	for _, target := range monitoringTargets {
+		for _, component := range target.components(target.newObject()) {
+			statefulSets[target.namespace()] = append(statefulSets[target.namespace()], component.statefulSet)
+		}
+	}
+	return statefulSets
+}()
+
+// PVCReconciler expands the PVCs of a single monitoring StatefulSet whenever the
+// StatefulSet, one of its PVCs or the owning CR changes, e.g. when CMO scales up
+// Alertmanager and the new replica's PVC is created at the old size. It is the
+// only writer of the pvcStatus and snapshots of the CR, so each failed
+// expansion is counted once.
+type PVCReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// Reconcile resizes the PVCs of the StatefulSet named by the request to the
+// volumeClaimTemplate configured in the owning Cluster or User CR, and records
+// failures and snapshots in that CR's status.
+func (r *PVCReconciler) Reconcile(reconcilerContext context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(reconcilerContext)
+
+	owner, err := r.owner(reconcilerContext, req.NamespacedName)
+	if err != nil {
+		log.Error(err, "Unable to fetch Monitoring Object")
+		return ctrl.Result{}, err
+	}
+	if owner.object == nil || !owner.object.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	result, pvcErr := reconcilePVCSize(reconcilerContext, r.Client, req.Namespace, req.Name, owner.vct, owner.snapshot)
+	if pvcErr != nil {
+		log.Error(pvcErr, "Unable to reconcile PVC sizes", "statefulSet", req.Name)
+	}
+
+	pvcStatus := setPVCStatus(*owner.pvcStatus, req.Name, result.failed, pvcErr)
+	snapshotStatus := setSnapshotStatus(*owner.snapshots, req.Name, result.snapshots)
+	if !equality.Semantic.DeepEqual(pvcStatus, *owner.pvcStatus) ||
+		!equality.Semantic.DeepEqual(snapshotStatus, *owner.snapshots) {
+		patch := client.MergeFrom(owner.object.DeepCopyObject().(client.Object))
+		*owner.pvcStatus = pvcStatus
+		*owner.snapshots = snapshotStatus
+		if err := r.Status().Patch(reconcilerContext, owner.object, patch); err != nil {
+			log.Error(err, "Unable to update Monitoring Object status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if pvcErr == nil && result.waiting {
+		return ctrl.Result{RequeueAfter: snapshotPollInterval}, nil
+	}
+	return ctrl.Result{}, pvcErr
+}
+
+// pvcOwner is the Cluster or User CR owning a monitoring StatefulSet, with the
+// settings its PVCs are reconciled from and the status fields they report to.
+type pvcOwner struct {
+	object    client.Object
+	vct       *corev1.PersistentVolumeClaimTemplate
+	snapshot  *monitoringv1.SnapshotBeforeChange
+	pvcStatus *[]monitoringv1.PVCStatus
+	snapshots *[]monitoringv1.PVCSnapshot
+}
+
+// owner returns the Cluster or User CR owning the StatefulSet. The returned
+// object is nil if the CR does not exist.
+func (r *PVCReconciler) owner(ctx context.Context, statefulSet types.NamespacedName) (pvcOwner, error) {
+	target := targetForNamespace(statefulSet.Namespace)
+	if target == nil {
+		return pvcOwner{}, nil
+	}
+	monitoring := target.newObject()
+	// The volumeClaimTemplate may be set by a fragment
+	if err := getMerged(ctx, r.Client, target, monitoring); err != nil {
+		return pvcOwner{}, client.IgnoreNotFound(err)
+	}
+	var vct *corev1.PersistentVolumeClaimTemplate
+	for _, component := range target.components(monitoring) {
+		if component.statefulSet == statefulSet.Name {
+			vct = component.vct
+		}
+	}
+	status := target.status(monitoring)
+	return pvcOwner{
+		object:    monitoring,
+		vct:       vct,
+		snapshot:  target.snapshot(monitoring),
+		pvcStatus: status.pvcStatus,
+		snapshots: status.snapshots,
+	}, nil
+}
+
+// isMonitoringStatefulSet filters events down to the StatefulSets listed in
+// monitoringStatefulSets.
+func isMonitoringStatefulSet(object client.Object) bool {
+	for _, name := range monitoringStatefulSets[object.GetNamespace()] {
+		if object.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// statefulSetForPVC maps a PVC named <volumeClaimTemplate>-<statefulSet>-<ordinal>
+// to the monitoring StatefulSet that owns it.
+func statefulSetForPVC(_ context.Context, object client.Object) []reconcile.Request {
+	// Only the ordinal is cut off, as a StatefulSet name may end in a digit too
+	index := strings.LastIndex(object.GetName(), "-")
+	if index < 0 {
+		return nil
+	}
+	withoutOrdinal, ordinal := object.GetName()[:index], object.GetName()[index+1:]
+	if _, err := strconv.ParseUint(ordinal, 10, 32); err != nil {
+		return nil
+	}
+	for _, name := range monitoringStatefulSets[object.GetNamespace()] {
+		if strings.HasSuffix(withoutOrdinal, "-"+name) {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: object.GetNamespace(), Name: name}}}
+		}
+	}
+	return nil
+}
+
+// enqueueStatefulSets maps the events of the CR of target, or of one of its
+// fragments, to every monitoring StatefulSet of target, as any of them may
+// take its volumeClaimTemplate or snapshotBeforeChange from it.
+func enqueueStatefulSets(target monitoringTarget) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+		var requests []reconcile.Request
+		for _, name := range monitoringStatefulSets[target.namespace()] {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: target.namespace(), Name: name}})
+		}
+		return requests
+	})
+}
+
+// pvcCapacityChanged filters PVC events down to those that can change the
+// capacity the retentionSize of target is derived from: a PVC of one of its
+// StatefulSets is created, deleted, expanded or resized. The capacity only
+// follows the requested size once the resize conditions clear, so changes to
+// either are passed on too.
+func pvcCapacityChanged(target monitoringTarget) predicate.Predicate {
+	owned := func(object client.Object) bool {
+		return object.GetNamespace() == target.namespace() && len(statefulSetForPVC(context.Background(), object)) > 0
+	}
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool { return owned(e.Object) },
+		DeleteFunc: func(e event.DeleteEvent) bool { return owned(e.Object) },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldPVC, oldOK := e.ObjectOld.(*corev1.PersistentVolumeClaim)
+			newPVC, newOK := e.ObjectNew.(*corev1.PersistentVolumeClaim)
+			return oldOK && newOK && owned(newPVC) &&
+				(!oldPVC.Status.Capacity.Storage().Equal(*newPVC.Status.Capacity.Storage()) ||
+					!oldPVC.Spec.Resources.Requests.Storage().Equal(*newPVC.Spec.Resources.Requests.Storage()) ||
+					!equality.Semantic.DeepEqual(oldPVC.Status.Conditions, newPVC.Status.Conditions))
+		},
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PVCReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("pvc").
+		For(&appsv1.StatefulSet{}, builder.WithPredicates(predicate.NewPredicateFuncs(isMonitoringStatefulSet))).
+		Watches(&corev1.PersistentVolumeClaim{}, handler.EnqueueRequestsFromMapFunc(statefulSetForPVC)).
+		// The CRs and their fragments set the size the PVCs are expanded to
+		Watches(&monitoringv1.Cluster{}, enqueueStatefulSets(clusterTarget{}),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&monitoringv1.ClusterFragment{}, enqueueStatefulSets(clusterTarget{}),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&monitoringv1.User{}, enqueueStatefulSets(userTarget{}),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&monitoringv1.UserFragment{}, enqueueStatefulSets(userTarget{}),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+func TestPVCCapacityChanged(t *testing.T) {
+	pvc := func(namespace, name, capacity string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(capacity)},
+			},
+		}
+	}
+	requested := func(pvc *corev1.PersistentVolumeClaim, size string) *corev1.PersistentVolumeClaim {
+		pvc.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)}
+		return pvc
+	}
+	resizing := func(pvc *corev1.PersistentVolumeClaim) *corev1.PersistentVolumeClaim {
+		pvc.Status.Conditions = []corev1.PersistentVolumeClaimCondition{{Type: corev1.PersistentVolumeClaimFileSystemResizePending, Status: corev1.ConditionTrue}}
+		return pvc
+	}
+	predicate := pvcCapacityChanged(clusterTarget{})
+	tests := []struct {
+		name     string
+		old, new *corev1.PersistentVolumeClaim
+		owned    bool
+		want     bool
+	}{
+		{
+			name:  "resized",
+			old:   pvc("openshift-monitoring", "prometheus-k8s-db-prometheus-k8s-0", "10Gi"),
+			new:   pvc("openshift-monitoring", "prometheus-k8s-db-prometheus-k8s-0", "20Gi"),
+			owned: true,
+			want:  true,
+		},
+		{
+			name:  "same capacity",
+			old:   pvc("openshift-monitoring", "prometheus-k8s-db-prometheus-k8s-0", "10Gi"),
+			new:   pvc("openshift-monitoring", "prometheus-k8s-db-prometheus-k8s-0", "10240Mi"),
+			owned: true,
+		},
+		{
+			name:  "expansion requested",
+			old:   requested(pvc("openshift-monitoring", "prometheus-k8s-db-prometheus-k8s-0", "10Gi"), "10Gi"),
+			new:   requested(pvc("openshift-monitoring", "prometheus-k8s-db-prometheus-k8s-0", "10Gi"), "20Gi"),
+			owned: true,
+			want:  true,
+		},
+		{
+			name:  "resize pending",
+			old:   pvc("openshift-monitoring", "prometheus-k8s-db-prometheus-k8s-0", "10Gi"),
+			new:   resizing(pvc("openshift-monitoring", "prometheus-k8s-db-prometheus-k8s-0", "10Gi")),
+			owned: true,
+			want:  true,
+		},
+		{
+			name: "not a monitoring PVC",
+			old:  pvc("openshift-monitoring", "data-0", "10Gi"),
+			new:  pvc("openshift-monitoring", "data-0", "20Gi"),
+		},
+		{
+			name: "other target",
+			old:  pvc("openshift-user-workload-monitoring", "prometheus-user-workload-db-prometheus-user-workload-0", "10Gi"),
+			new:  pvc("openshift-user-workload-monitoring", "prometheus-user-workload-db-prometheus-user-workload-0", "20Gi"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := predicate.Update(event.UpdateEvent{ObjectOld: test.old, ObjectNew: test.new}); got != test.want {
+				t.Errorf("Update = %v, want %v", got, test.want)
+			}
+			if got := predicate.Create(event.CreateEvent{Object: test.new}); got != test.owned {
+				t.Errorf("Create = %v, want %v", got, test.owned)
+			}
+		})
+	}
+}
+
+func TestStatefulSetForPVC(t *testing.T) {
+	statefulSets := monitoringStatefulSets
+	t.Cleanup(func() { monitoringStatefulSets = statefulSets })
+	monitoringStatefulSets = map[string][]string{
+		"openshift-monitoring": {"prometheus-k8s", "alertmanager-main", "thanos-ruler-2"},
+	}
+
+	tests := []struct {
+		namespace string
+		name      string
+		want      string
+	}{
+		{namespace: "openshift-monitoring", name: "prometheus-k8s-db-prometheus-k8s-0", want: "prometheus-k8s"},
+		{namespace: "openshift-monitoring", name: "alertmanager-main-db-alertmanager-main-12", want: "alertmanager-main"},
+		{namespace: "openshift-monitoring", name: "data-thanos-ruler-2-0", want: "thanos-ruler-2"},
+		{namespace: "openshift-monitoring", name: "prometheus-k8s-db-prometheus-k8s"},
+		{namespace: "openshift-monitoring", name: "prometheus-k8s-db-prometheus-k8s-x"},
+		{namespace: "openshift-monitoring", name: "data-thanos-ruler-20"},
+		{namespace: "openshift-monitoring", name: "data"},
+		{namespace: "default", name: "prometheus-k8s-db-prometheus-k8s-0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace+"/"+tt.name, func(t *testing.T) {
+			pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: tt.namespace, Name: tt.name}}
+			requests := statefulSetForPVC(context.Background(), pvc)
+			switch {
+			case tt.want == "" && len(requests) != 0:
+				t.Errorf("statefulSetForPVC = %v, want none", requests)
+			case tt.want != "" && (len(requests) != 1 || requests[0].Name != tt.want || requests[0].Namespace != tt.namespace):
+				t.Errorf("statefulSetForPVC = %v, want %s/%s", requests, tt.namespace, tt.want)
+			}
+		})
+	}
+}
+
+func TestPVCReconcilerOwnsPVCStatus(t *testing.T) {
+	cluster := &monitoringv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-monitoring-config"},
+		Spec: monitoringv1.ClusterSpec{
+			PrometheusK8S: monitoringv1.PrometheusK8S{
+				VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+					Spec: corev1.PersistentVolumeClaimSpec{
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("20Gi")},
+						},
+					},
+				},
+			},
+		},
+	}
+	labels := map[string]string{"app.kubernetes.io/name": "prometheus"}
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-monitoring", Name: "prometheus-k8s"},
+		Spec: appsv1.StatefulSetSpec{
+			Selector:             &metav1.LabelSelector{MatchLabels: labels},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "prometheus-k8s-db"}}},
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-monitoring", Name: "prometheus-k8s-db-prometheus-k8s-0", Labels: labels},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+			},
+		},
+	}
+	c := newFakeClient(t, cluster, statefulSet, pvc)
+	failing := interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, object client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if _, ok := object.(*corev1.PersistentVolumeClaim); ok {
+				return errors.New("expansion not supported")
+			}
+			return c.Patch(ctx, object, patch, opts...)
+		},
+	})
+	request := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(statefulSet)}
+	retryCount := func() int32 {
+		t.Helper()
+		if err := c.Get(context.Background(), client.ObjectKeyFromObject(cluster), cluster); err != nil {
+			t.Fatal(err)
+		}
+		if len(cluster.Status.PVCStatus) != 1 {
+			return 0
+		}
+		return cluster.Status.PVCStatus[0].RetryCount
+	}
+
+	// Each failed expansion is counted once, however often the CR is reconciled
+	for i := int32(1); i <= 2; i++ {
+		if _, err := (&PVCReconciler{Client: failing}).Reconcile(context.Background(), request); err == nil {
+			t.Fatal("Reconcile of an unexpandable PVC succeeded")
+		}
+		reconcileTarget(t, failing, clusterTarget{})
+		if got := retryCount(); got != i {
+			t.Errorf("retryCount after %d failures = %d, want %d", i, got, i)
+		}
+	}
+
+	// The CR reconciler only reads the PVCs
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(pvc), pvc); err != nil {
+		t.Fatal(err)
+	}
+	if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.Cmp(resource.MustParse("10Gi")) != 0 {
+		t.Errorf("PVC request after the CR reconcile = %s, want 10Gi", size.String())
+	}
+
+	if _, err := (&PVCReconciler{Client: c}).Reconcile(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(pvc), pvc); err != nil {
+		t.Fatal(err)
+	}
+	if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.Cmp(resource.MustParse("20Gi")) != 0 {
+		t.Errorf("PVC request = %s, want 20Gi", size.String())
+	}
+	if got := retryCount(); got != 0 {
+		t.Errorf("retryCount after the expansion = %d, want the entry dropped", got)
+	}
+}
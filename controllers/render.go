@@ -0,0 +1,238 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	objectMetaType    = reflect.TypeOf(metav1.ObjectMeta{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// renderConfig renders a spec as the config.yaml of a CMO ConfigMap. The spec
+// is walked by its json tags, so the output follows the CRD field names, but:
+//   - empty values and sections are left out, unless set through a pointer,
+//   - embedded ObjectMeta, i.e. the metadata of a volumeClaimTemplate, only
+//     keeps the name, labels and annotations CMO passes on to the PVCs, rather
+//     than fields such as a null creationTimestamp that CMO rejects.
+//
+// Map keys are sorted, so the same spec always renders to the same bytes.
+func renderConfig(spec interface{}) (string, error) {
+	tree, err := renderValue(reflect.ValueOf(spec))
+	if err != nil {
+		return "", err
+	}
+	if tree == nil {
+		tree = map[string]interface{}{}
+	}
+	out, err := yaml.Marshal(tree)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// renderValue converts v into maps, slices and scalars, returning nil for
+// values that are empty in the sense of the json omitempty option, or for
+// structs without any field set.
+func renderValue(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.Type() == objectMetaType {
+		return renderObjectMeta(v.Interface().(metav1.ObjectMeta)), nil
+	}
+	// Types like resource.Quantity define their own encoding
+	if v.Type().Implements(jsonMarshalerType) || reflect.PointerTo(v.Type()).Implements(jsonMarshalerType) {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return nil, nil
+		}
+		return renderMarshaler(v)
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return renderValue(v.Elem())
+	case reflect.Struct:
+		out := make(map[string]interface{})
+		if err := renderFields(v, out); err != nil {
+			return nil, err
+		}
+		if len(out) == 0 {
+			return nil, nil
+		}
+		return out, nil
+	case reflect.Map:
+		if v.Len() == 0 {
+			return nil, nil
+		}
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			value, err := renderValue(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			if value == nil {
+				value = zeroValue(iter.Value())
+			}
+			out[fmt.Sprint(iter.Key().Interface())] = value
+		}
+		return out, nil
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return nil, nil
+		}
+		out := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			value, err := renderValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			if value == nil {
+				value = zeroValue(v.Index(i))
+			}
+			out[i] = value
+		}
+		return out, nil
+	case reflect.Bool:
+		if !v.Bool() {
+			return nil, nil
+		}
+		return v.Bool(), nil
+	case reflect.String:
+		if v.String() == "" {
+			return nil, nil
+		}
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() == 0 {
+			return nil, nil
+		}
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() == 0 {
+			return nil, nil
+		}
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		if v.Float() == 0 {
+			return nil, nil
+		}
+		return v.Float(), nil
+	}
+	return nil, fmt.Errorf("unable to render %s", v.Type())
+}
+
+// renderFields adds the fields of struct v to out, flattening inline fields.
+// A field behind a pointer is rendered even if zero, e.g. enabled: false or a
+// set but empty section.
+func renderFields(v reflect.Value, out map[string]interface{}) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if opts == "inline" || (name == "" && field.Anonymous) {
+			if err := renderFields(reflect.Indirect(v.Field(i)), out); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		value, err := renderValue(v.Field(i))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if value == nil && v.Field(i).Kind() == reflect.Pointer && !v.Field(i).IsNil() {
+			value = zeroValue(v.Field(i))
+		}
+		if value != nil {
+			out[name] = value
+		}
+	}
+	return nil
+}
+
+// zeroValue is what a set but zero value renders as.
+func zeroValue(v reflect.Value) interface{} {
+	v = reflect.Indirect(v)
+	switch v.Kind() {
+	case reflect.Bool:
+		return false
+	case reflect.String:
+		return ""
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return 0
+	case reflect.Slice, reflect.Array:
+		return []interface{}{}
+	}
+	return map[string]interface{}{}
+}
+
+// renderObjectMeta keeps the metadata CMO copies from a volumeClaimTemplate.
+func renderObjectMeta(meta metav1.ObjectMeta) interface{} {
+	out := make(map[string]interface{})
+	if meta.Name != "" {
+		out["name"] = meta.Name
+	}
+	if len(meta.Labels) > 0 {
+		out["labels"] = meta.Labels
+	}
+	if len(meta.Annotations) > 0 {
+		out["annotations"] = meta.Annotations
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// renderMarshaler renders a value through its own JSON encoding.
+func renderMarshaler(v reflect.Value) (interface{}, error) {
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var out interface{}
+	if err := decoder.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+func TestRenderConfig(t *testing.T) {
+	spec := &monitoringv1.ClusterSpec{
+		EnableUserWorkload: true,
+		AlertmanagerMain: monitoringv1.AlertmanagerMain{
+			Enabled: BoolPointer(false),
+		},
+		PrometheusK8S: monitoringv1.PrometheusK8S{
+			CommonPodSettings: monitoringv1.CommonPodSettings{
+				LogLevel: "debug",
+				PodSettings: monitoringv1.PodSettings{
+					Resources: &corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+					},
+				},
+			},
+			Retention: "7d",
+			VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"backup": "daily"},
+					Annotations: map[string]string{"example.com/owner": "monitoring"},
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					StorageClassName: stringPointer("fast"),
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("100Gi")},
+					},
+				},
+			},
+		},
+	}
+
+	// Keys are sorted, empty sections are left out and the VCT keeps its
+	// labels and annotations but not a null creationTimestamp.
+	want := `alertmanagerMain:
+  enabled: false
+enableUserWorkload: true
+prometheusK8s:
+  logLevel: debug
+  resources:
+    requests:
+      memory: 2Gi
+  retention: 7d
+  volumeClaimTemplate:
+    metadata:
+      annotations:
+        example.com/owner: monitoring
+      labels:
+        backup: daily
+    spec:
+      resources:
+        requests:
+          storage: 100Gi
+      storageClassName: fast
+`
+	for i := 0; i < 3; i++ {
+		got, err := renderConfig(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("renderConfig() =\n%s\nwant\n%s", got, want)
+		}
+	}
+}
+
+func TestRenderConfigEmpty(t *testing.T) {
+	got, err := renderConfig(&monitoringv1.UserSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "{}\n" {
+		t.Errorf("renderConfig() = %q, want {}", got)
+	}
+}
+
+func TestClusterRenderTelemeterToken(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "telemeter", Namespace: "openshift-monitoring"},
+		Data:       map[string][]byte{"token": []byte("from-secret")},
+	}
+	tokenSecret := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "telemeter"}, Key: "token"}
+	tests := []struct {
+		name   string
+		client monitoringv1.TelemeterClient
+		want   string
+	}{
+		{
+			name:   "tokenSecret",
+			client: monitoringv1.TelemeterClient{ClusterID: "id", TokenSecret: tokenSecret},
+			want:   "telemeterClient:\n  clusterID: id\n  token: from-secret\n",
+		},
+		{
+			name:   "deprecated token",
+			client: monitoringv1.TelemeterClient{ClusterID: "id", Token: "inline"},
+			want:   "telemeterClient:\n  clusterID: id\n  token: inline\n",
+		},
+		{
+			name:   "tokenSecret wins over token",
+			client: monitoringv1.TelemeterClient{Token: "inline", TokenSecret: tokenSecret},
+			want:   "telemeterClient:\n  token: from-secret\n",
+		},
+		{
+			name:   "no token",
+			client: monitoringv1.TelemeterClient{ClusterID: "id"},
+			want:   "telemeterClient:\n  clusterID: id\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitoring := &monitoringv1.Cluster{Spec: monitoringv1.ClusterSpec{TelemeterClient: tt.client}}
+			spec, err := clusterTarget{}.render(context.Background(), newFakeClient(t, secret), monitoring, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := renderConfig(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("renderConfig() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
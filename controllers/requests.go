@@ -0,0 +1,199 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+const conditionAccepted = "Accepted"
+
+// requestLimit is a limit a UserWorkloadRequest can raise.
+type requestLimit struct {
+	field     monitoringv1.UserWorkloadRequestField
+	requested func(*monitoringv1.UserWorkloadRequestSpec) *int64
+	maximum   func(*monitoringv1.UserWorkloadRequestPolicy) *int64
+	current   func(*monitoringv1.UserSpec) *int64
+}
+
+var requestLimits = []requestLimit{
+	{
+		field:     "enforcedSampleLimit",
+		requested: func(spec *monitoringv1.UserWorkloadRequestSpec) *int64 { return spec.EnforcedSampleLimit },
+		maximum:   func(policy *monitoringv1.UserWorkloadRequestPolicy) *int64 { return policy.MaxEnforcedSampleLimit },
+		current:   func(spec *monitoringv1.UserSpec) *int64 { return spec.Prometheus.EnforcedSampleLimit },
+	},
+	{
+		field:     "enforcedTargetLimit",
+		requested: func(spec *monitoringv1.UserWorkloadRequestSpec) *int64 { return spec.EnforcedTargetLimit },
+		maximum:   func(policy *monitoringv1.UserWorkloadRequestPolicy) *int64 { return policy.MaxEnforcedTargetLimit },
+		current:   func(spec *monitoringv1.UserSpec) *int64 { return spec.Prometheus.EnforcedTargetLimit },
+	},
+}
+
+// checkRequest decides a request against the policy of the User CR.
+func checkRequest(policy *monitoringv1.UserWorkloadRequestPolicy, request *monitoringv1.UserWorkloadRequest) *rejection {
+	if policy == nil {
+		return &rejection{reason: "NoPolicy", message: "the User CR does not set requestPolicy"}
+	}
+	var fields []monitoringv1.UserWorkloadRequestField
+	if request.Spec.ExcludeFromLabelEnforcement {
+		fields = append(fields, "excludeFromLabelEnforcement")
+	}
+	for _, limit := range requestLimits {
+		if limit.requested(&request.Spec) != nil {
+			fields = append(fields, limit.field)
+		}
+	}
+	for _, field := range fields {
+		if !slices.Contains(policy.AllowedFields, field) {
+			return &rejection{reason: "FieldNotAllowed", message: fmt.Sprintf("requestPolicy does not allow %s", field)}
+		}
+	}
+	// The limits are those of the single user workload Prometheus
+	for _, limit := range requestLimits {
+		if limit.requested(&request.Spec) != nil && !policy.AllowClusterWideLimits {
+			return &rejection{reason: "ClusterWideLimit", message: fmt.Sprintf("%s applies to every namespace, which requestPolicy does not allow without allowClusterWideLimits", limit.field)}
+		}
+	}
+	for _, limit := range requestLimits {
+		requested, maximum := limit.requested(&request.Spec), limit.maximum(policy)
+		if requested != nil && maximum != nil && *requested > *maximum {
+			return &rejection{reason: "AboveMaximum", message: fmt.Sprintf("%s %d is above the maximum of %d", limit.field, *requested, *maximum)}
+		}
+	}
+	return nil
+}
+
+// ineffectiveRequest returns why an accepted request changes nothing in spec,
+// the User spec before any request is applied, or nil if it changes something.
+func ineffectiveRequest(spec *monitoringv1.UserSpec, request *monitoringv1.UserWorkloadRequest) *rejection {
+	if request.Spec.ExcludeFromLabelEnforcement && !slices.Contains(spec.NamespacesWithoutLabelEnforcement, request.Namespace) {
+		return nil
+	}
+	for _, limit := range requestLimits {
+		current, requested := limit.current(spec), limit.requested(&request.Spec)
+		if current != nil && *current > 0 && requested != nil && *requested > *current {
+			return nil
+		}
+	}
+	return &rejection{reason: "NoEffect", message: "the User spec already meets the request, as the namespace is excluded from label enforcement and the limits are unset or at least as high"}
+}
+
+// applyRequests applies the requests accepted by spec.requestPolicy to spec
+// and returns the rejected ones, and those without effect, by namespace/name.
+// Limits only ever go up: a limit is raised to the highest accepted request,
+// but not set where the spec leaves it unlimited. Namespaces are added in
+// namespace/name order, so the result does not depend on the order requests
+// are listed in.
+func applyRequests(spec *monitoringv1.UserSpec, requests []monitoringv1.UserWorkloadRequest) map[string]rejection {
+	sorted := make([]*monitoringv1.UserWorkloadRequest, len(requests))
+	for i := range requests {
+		sorted[i] = &requests[i]
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return client.ObjectKeyFromObject(sorted[i]).String() < client.ObjectKeyFromObject(sorted[j]).String()
+	})
+
+	// Whether a request has an effect does not depend on the other requests
+	base := spec.DeepCopy()
+	rejected := make(map[string]rejection)
+	for _, request := range sorted {
+		if r := checkRequest(spec.RequestPolicy, request); r != nil {
+			rejected[client.ObjectKeyFromObject(request).String()] = *r
+			continue
+		}
+		if r := ineffectiveRequest(base, request); r != nil {
+			rejected[client.ObjectKeyFromObject(request).String()] = *r
+			continue
+		}
+		if request.Spec.ExcludeFromLabelEnforcement && !slices.Contains(spec.NamespacesWithoutLabelEnforcement, request.Namespace) {
+			spec.NamespacesWithoutLabelEnforcement = append(spec.NamespacesWithoutLabelEnforcement, request.Namespace)
+		}
+		for _, limit := range requestLimits {
+			current, requested := limit.current(spec), limit.requested(&request.Spec)
+			// Zero disables the limit in Prometheus
+			if current != nil && *current > 0 && requested != nil && *requested > *current {
+				*current = *requested
+			}
+		}
+	}
+	return rejected
+}
+
+// appliedRequests are the UserWorkloadRequests listed for a spec, with those
+// that were not applied to it by namespace/name.
+type appliedRequests struct {
+	items    []monitoringv1.UserWorkloadRequest
+	rejected map[string]rejection
+}
+
+// applyUserWorkloadRequests lists the UserWorkloadRequests and applies the
+// accepted ones to spec. Their status is only updated by updateRequestStatus,
+// once the outcome of the spec is known.
+func applyUserWorkloadRequests(ctx context.Context, c client.Reader, spec *monitoringv1.UserSpec) (appliedRequests, error) {
+	var list monitoringv1.UserWorkloadRequestList
+	if err := c.List(ctx, &list); err != nil {
+		return appliedRequests{}, err
+	}
+	return appliedRequests{items: list.Items, rejected: applyRequests(spec, list.Items)}, nil
+}
+
+// updateRequestStatus records the outcome in each request. Unless the spec
+// they were applied to is rendered, the accepted requests are reported as not
+// applied yet, as pending holds the reason.
+func updateRequestStatus(ctx context.Context, c client.Client, requests appliedRequests, pending *rejection) error {
+	for i := range requests.items {
+		request := &requests.items[i]
+		original := request.DeepCopy()
+		condition := metav1.Condition{
+			Type:               conditionAccepted,
+			Status:             metav1.ConditionTrue,
+			Reason:             "Accepted",
+			ObservedGeneration: request.Generation,
+		}
+		if r, ok := requests.rejected[client.ObjectKeyFromObject(request).String()]; ok {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = r.reason
+			condition.Message = r.message
+		} else if pending != nil {
+			condition.Status = metav1.ConditionUnknown
+			condition.Reason = pending.reason
+			condition.Message = pending.message
+		}
+		meta.SetStatusCondition(&request.Status.Conditions, condition)
+		if equality.Semantic.DeepEqual(original, request) {
+			continue
+		}
+		if err := c.Status().Patch(ctx, request, client.MergeFrom(original)); err != nil {
+			log.FromContext(ctx).Error(err, "Unable to update UserWorkloadRequest status", "request", client.ObjectKeyFromObject(request))
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+func userWorkloadRequest(namespace string, spec monitoringv1.UserWorkloadRequestSpec) monitoringv1.UserWorkloadRequest {
+	return monitoringv1.UserWorkloadRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "monitoring"},
+		Spec:       spec,
+	}
+}
+
+func TestApplyRequests(t *testing.T) {
+	policy := &monitoringv1.UserWorkloadRequestPolicy{
+		AllowedFields:          []monitoringv1.UserWorkloadRequestField{"excludeFromLabelEnforcement", "enforcedSampleLimit"},
+		AllowClusterWideLimits: true,
+		MaxEnforcedSampleLimit: int64Pointer(100000),
+	}
+	tests := []struct {
+		name      string
+		spec      monitoringv1.UserSpec
+		requests  []monitoringv1.UserWorkloadRequest
+		want      monitoringv1.UserSpec
+		rejection map[string]string
+	}{
+		{
+			name: "no policy",
+			spec: monitoringv1.UserSpec{},
+			requests: []monitoringv1.UserWorkloadRequest{
+				userWorkloadRequest("team-a", monitoringv1.UserWorkloadRequestSpec{ExcludeFromLabelEnforcement: true}),
+			},
+			want:      monitoringv1.UserSpec{},
+			rejection: map[string]string{"team-a/monitoring": "NoPolicy"},
+		},
+		{
+			name: "accepted",
+			spec: monitoringv1.UserSpec{
+				RequestPolicy:                     policy,
+				Prometheus:                        monitoringv1.Prometheus{EnforcedSampleLimit: int64Pointer(50000)},
+				NamespacesWithoutLabelEnforcement: []string{"team-c"},
+			},
+			// Listed out of order, the namespaces are still added in order
+			requests: []monitoringv1.UserWorkloadRequest{
+				userWorkloadRequest("team-b", monitoringv1.UserWorkloadRequestSpec{ExcludeFromLabelEnforcement: true, EnforcedSampleLimit: int64Pointer(60000)}),
+				userWorkloadRequest("team-a", monitoringv1.UserWorkloadRequestSpec{ExcludeFromLabelEnforcement: true, EnforcedSampleLimit: int64Pointer(80000)}),
+				userWorkloadRequest("team-c", monitoringv1.UserWorkloadRequestSpec{EnforcedSampleLimit: int64Pointer(10000)}),
+			},
+			want: monitoringv1.UserSpec{
+				RequestPolicy:                     policy,
+				Prometheus:                        monitoringv1.Prometheus{EnforcedSampleLimit: int64Pointer(80000)},
+				NamespacesWithoutLabelEnforcement: []string{"team-c", "team-a", "team-b"},
+			},
+			// team-c asks for less than the limit and is excluded already
+			rejection: map[string]string{"team-c/monitoring": "NoEffect"},
+		},
+		{
+			name: "unlimited stays unlimited",
+			spec: monitoringv1.UserSpec{RequestPolicy: policy},
+			requests: []monitoringv1.UserWorkloadRequest{
+				userWorkloadRequest("team-a", monitoringv1.UserWorkloadRequestSpec{EnforcedSampleLimit: int64Pointer(80000)}),
+			},
+			want:      monitoringv1.UserSpec{RequestPolicy: policy},
+			rejection: map[string]string{"team-a/monitoring": "NoEffect"},
+		},
+		{
+			name: "cluster-wide limits not allowed",
+			spec: monitoringv1.UserSpec{
+				RequestPolicy: &monitoringv1.UserWorkloadRequestPolicy{AllowedFields: policy.AllowedFields},
+				Prometheus:    monitoringv1.Prometheus{EnforcedSampleLimit: int64Pointer(50000)},
+			},
+			requests: []monitoringv1.UserWorkloadRequest{
+				userWorkloadRequest("team-a", monitoringv1.UserWorkloadRequestSpec{EnforcedSampleLimit: int64Pointer(80000)}),
+			},
+			want: monitoringv1.UserSpec{
+				RequestPolicy: &monitoringv1.UserWorkloadRequestPolicy{AllowedFields: policy.AllowedFields},
+				Prometheus:    monitoringv1.Prometheus{EnforcedSampleLimit: int64Pointer(50000)},
+			},
+			rejection: map[string]string{"team-a/monitoring": "ClusterWideLimit"},
+		},
+		{
+			name: "rejected",
+			spec: monitoringv1.UserSpec{
+				RequestPolicy: policy,
+				Prometheus:    monitoringv1.Prometheus{EnforcedSampleLimit: int64Pointer(50000), EnforcedTargetLimit: int64Pointer(10)},
+			},
+			requests: []monitoringv1.UserWorkloadRequest{
+				userWorkloadRequest("team-a", monitoringv1.UserWorkloadRequestSpec{EnforcedSampleLimit: int64Pointer(200000)}),
+				userWorkloadRequest("team-b", monitoringv1.UserWorkloadRequestSpec{ExcludeFromLabelEnforcement: true, EnforcedTargetLimit: int64Pointer(20)}),
+			},
+			want: monitoringv1.UserSpec{
+				RequestPolicy: policy,
+				Prometheus:    monitoringv1.Prometheus{EnforcedSampleLimit: int64Pointer(50000), EnforcedTargetLimit: int64Pointer(10)},
+			},
+			rejection: map[string]string{"team-a/monitoring": "AboveMaximum", "team-b/monitoring": "FieldNotAllowed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := tt.spec.DeepCopy()
+			rejected := applyRequests(spec, tt.requests)
+			if !reflect.DeepEqual(*spec, tt.want) {
+				t.Errorf("spec = %+v, want %+v", *spec, tt.want)
+			}
+			reasons := make(map[string]string)
+			for key, r := range rejected {
+				reasons[key] = r.reason
+			}
+			if !reflect.DeepEqual(reasons, tt.rejection) {
+				t.Errorf("rejected = %v, want %v", reasons, tt.rejection)
+			}
+		})
+	}
+}
+
+func TestMonitoringReconcilerAppliesRequests(t *testing.T) {
+	user := &monitoringv1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: "user-workload-monitoring-config"},
+		Spec: monitoringv1.UserSpec{
+			RequestPolicy: &monitoringv1.UserWorkloadRequestPolicy{
+				AllowedFields: []monitoringv1.UserWorkloadRequestField{"excludeFromLabelEnforcement"},
+			},
+		},
+	}
+	request := userWorkloadRequest("team-a", monitoringv1.UserWorkloadRequestSpec{ExcludeFromLabelEnforcement: true})
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}
+	c := newFakeClient(t, user, &request, namespace)
+	target := userTarget{}
+	reconcileTarget(t, c, target)
+
+	want := "namespacesWithoutLabelEnforcement:\n- team-a\n"
+	if got := renderedConfig(t, c, target); got != want {
+		t.Errorf("config.yaml = %q, want %q without the requestPolicy", got, want)
+	}
+
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(&request), &request); err != nil {
+		t.Fatal(err)
+	}
+	if !meta.IsStatusConditionTrue(request.Status.Conditions, conditionAccepted) {
+		t.Errorf("conditions = %+v, want Accepted", request.Status.Conditions)
+	}
+}
+
+func TestMonitoringReconcilerHoldsRequestsOfInvalidSpec(t *testing.T) {
+	user := &monitoringv1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: "user-workload-monitoring-config"},
+		Spec: monitoringv1.UserSpec{
+			RequestPolicy: &monitoringv1.UserWorkloadRequestPolicy{
+				AllowedFields: []monitoringv1.UserWorkloadRequestField{"excludeFromLabelEnforcement"},
+			},
+			Alertmanager: monitoringv1.Alertmanager{Secrets: []string{"alertmanager-tls"}},
+		},
+	}
+	request := userWorkloadRequest("team-a", monitoringv1.UserWorkloadRequestSpec{ExcludeFromLabelEnforcement: true})
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}
+	c := newFakeClient(t, user, &request, namespace)
+	target := userTarget{}
+	accepted := func() *metav1.Condition {
+		t.Helper()
+		if err := c.Get(context.Background(), client.ObjectKeyFromObject(&request), &request); err != nil {
+			t.Fatal(err)
+		}
+		return meta.FindStatusCondition(request.Status.Conditions, conditionAccepted)
+	}
+
+	// The missing Secret makes the spec invalid, so nothing is rendered
+	reconcileTarget(t, c, target)
+	if condition := accepted(); condition == nil || condition.Status != metav1.ConditionUnknown || condition.Reason != "NotApplied" {
+		t.Errorf("Accepted = %+v, want Unknown with reason NotApplied", condition)
+	}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: target.namespace(), Name: "alertmanager-tls"}}
+	if err := c.Create(context.Background(), secret); err != nil {
+		t.Fatal(err)
+	}
+	reconcileTarget(t, c, target)
+	if condition := accepted(); condition == nil || condition.Status != metav1.ConditionTrue {
+		t.Errorf("Accepted = %+v, want True once the spec is rendered", condition)
+	}
+}
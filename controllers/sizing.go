@@ -0,0 +1,168 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+// sizingPreset is what a sizing preset sets for a component. Storage and
+// retention are left unset for components without them.
+type sizingPreset struct {
+	cpu, memory, memoryLimit string
+	storage                  string
+	retention                monitoringv1.Duration
+}
+
+// sizingPresets are the presets by sizing and kind of component. Prometheus is
+// both prometheusK8s and the user workload prometheus.
+var sizingPresets = map[monitoringv1.Sizing]map[string]sizingPreset{
+	monitoringv1.SizingSmall: {
+		"prometheus":    {cpu: "200m", memory: "2Gi", memoryLimit: "4Gi", storage: "20Gi", retention: "7d"},
+		"alertmanager":  {cpu: "10m", memory: "128Mi", memoryLimit: "256Mi", storage: "2Gi"},
+		"thanosQuerier": {cpu: "10m", memory: "64Mi", memoryLimit: "256Mi"},
+		"thanosRuler":   {cpu: "10m", memory: "128Mi", memoryLimit: "512Mi", storage: "10Gi", retention: "24h"},
+	},
+	monitoringv1.SizingMedium: {
+		"prometheus":    {cpu: "500m", memory: "4Gi", memoryLimit: "8Gi", storage: "50Gi", retention: "15d"},
+		"alertmanager":  {cpu: "20m", memory: "256Mi", memoryLimit: "512Mi", storage: "5Gi"},
+		"thanosQuerier": {cpu: "50m", memory: "256Mi", memoryLimit: "1Gi"},
+		"thanosRuler":   {cpu: "50m", memory: "256Mi", memoryLimit: "1Gi", storage: "20Gi", retention: "3d"},
+	},
+	monitoringv1.SizingLarge: {
+		"prometheus":    {cpu: "1", memory: "8Gi", memoryLimit: "16Gi", storage: "100Gi", retention: "30d"},
+		"alertmanager":  {cpu: "50m", memory: "512Mi", memoryLimit: "1Gi", storage: "10Gi"},
+		"thanosQuerier": {cpu: "100m", memory: "512Mi", memoryLimit: "2Gi"},
+		"thanosRuler":   {cpu: "100m", memory: "512Mi", memoryLimit: "2Gi", storage: "50Gi", retention: "7d"},
+	},
+}
+
+// sizedComponent points into the fields of a component that a sizing preset
+// expands into. vct and retention are nil for components without them.
+type sizedComponent struct {
+	// path is the section of the spec, kind the key of the preset.
+	path, kind string
+	resources  **corev1.ResourceRequirements
+	vct        **corev1.PersistentVolumeClaimTemplate
+	retention  *monitoringv1.Duration
+}
+
+// expandSizing fills in what the sizing preset sets and the components leave
+// unset, and returns the effective sizing of each component. Without a sizing
+// nothing is changed or returned.
+func expandSizing(sizing monitoringv1.Sizing, components []sizedComponent) []monitoringv1.ComponentSizing {
+	if sizing == "" {
+		return nil
+	}
+	effective := make([]monitoringv1.ComponentSizing, 0, len(components))
+	for _, component := range components {
+		preset, ok := sizingPresets[sizing][component.kind]
+		if ok {
+			expandResources(component.resources, preset)
+			if component.vct != nil && preset.storage != "" {
+				expandStorage(component.vct, preset.storage)
+			}
+			if component.retention != nil && *component.retention == "" {
+				*component.retention = preset.retention
+			}
+		}
+
+		sized := monitoringv1.ComponentSizing{Component: component.path}
+		if *component.resources != nil {
+			sized.Resources = (*component.resources).DeepCopy()
+		}
+		if component.vct != nil && *component.vct != nil {
+			if storage, ok := (*component.vct).Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+				sized.Storage = &storage
+			}
+		}
+		if component.retention != nil {
+			sized.Retention = *component.retention
+		}
+		effective = append(effective, sized)
+	}
+	return effective
+}
+
+// expandResources sets the preset CPU and memory unless the resources set a
+// request or limit for them.
+func expandResources(resources **corev1.ResourceRequirements, preset sizingPreset) {
+	fillResources(resources, corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(preset.cpu),
+			corev1.ResourceMemory: resource.MustParse(preset.memory),
+		},
+		Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(preset.memoryLimit)},
+	})
+}
+
+// fillResources copies the requests and limits of each resource in fill unless
+// the resources set a request or limit for it.
+func fillResources(resources **corev1.ResourceRequirements, fill corev1.ResourceRequirements) {
+	if *resources == nil {
+		*resources = &corev1.ResourceRequirements{}
+	}
+	r := *resources
+	unset := func(name corev1.ResourceName) bool {
+		_, requested := r.Requests[name]
+		_, limited := r.Limits[name]
+		return !requested && !limited
+	}
+	// Resources are checked before any is set, as a request and a limit of the
+	// same resource are filled together.
+	var names []corev1.ResourceName
+	for _, list := range []corev1.ResourceList{fill.Requests, fill.Limits} {
+		for name := range list {
+			if unset(name) && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	for _, name := range names {
+		if value, ok := fill.Requests[name]; ok {
+			r.Requests = setResource(r.Requests, name, value)
+		}
+		if value, ok := fill.Limits[name]; ok {
+			r.Limits = setResource(r.Limits, name, value)
+		}
+	}
+}
+
+// expandStorage requests storage in the volumeClaimTemplate, creating it if
+// needed, unless it already requests storage.
+func expandStorage(vct **corev1.PersistentVolumeClaimTemplate, storage string) {
+	if *vct == nil {
+		*vct = &corev1.PersistentVolumeClaimTemplate{}
+	}
+	resources := &(*vct).Spec.Resources
+	if _, ok := resources.Requests[corev1.ResourceStorage]; !ok {
+		resources.Requests = setResource(resources.Requests, corev1.ResourceStorage, resource.MustParse(storage))
+	}
+}
+
+func setResource(list corev1.ResourceList, name corev1.ResourceName, value resource.Quantity) corev1.ResourceList {
+	if list == nil {
+		list = corev1.ResourceList{}
+	}
+	list[name] = value.DeepCopy()
+	return list
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+func TestExpandSizing(t *testing.T) {
+	tests := []struct {
+		name   string
+		object client.Object
+		target monitoringTarget
+		want   []monitoringv1.ComponentSizing
+	}{
+		{
+			// The memory limit set in the spec wins over the whole memory
+			// preset, the CPU and storage come from the preset, and the
+			// disabled Alertmanager is left alone.
+			name: "preset",
+			object: &monitoringv1.Cluster{Spec: monitoringv1.ClusterSpec{
+				Sizing: monitoringv1.SizingMedium,
+				PrometheusK8S: monitoringv1.PrometheusK8S{
+					CommonPodSettings: monitoringv1.CommonPodSettings{
+						PodSettings: monitoringv1.PodSettings{
+							Resources: &corev1.ResourceRequirements{
+								Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("6Gi")},
+							},
+						},
+					},
+					Retention: "3d",
+				},
+				AlertmanagerMain: monitoringv1.AlertmanagerMain{Enabled: BoolPointer(false)},
+			}},
+			target: clusterTarget{},
+			want: []monitoringv1.ComponentSizing{
+				{
+					Component: "prometheusK8s",
+					Resources: &corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+						Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("6Gi")},
+					},
+					Storage:   quantityPointer("50Gi"),
+					Retention: "3d",
+				},
+				{
+					Component: "thanosQuerier",
+					Resources: &corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m"), corev1.ResourceMemory: resource.MustParse("256Mi")},
+						Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+					},
+				},
+			},
+		},
+		{
+			name:   "none",
+			object: &monitoringv1.User{},
+			target: userTarget{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tt.object.DeepCopyObject()
+			if sizing := expandSizing(tt.target.sizing(tt.object)); !equality.Semantic.DeepEqual(sizing, tt.want) {
+				t.Errorf("sizing = %+v, want %+v", sizing, tt.want)
+			}
+			if tt.want == nil && !equality.Semantic.DeepEqual(tt.object, before) {
+				t.Errorf("object = %+v, want it unchanged without sizing", tt.object)
+			}
+		})
+	}
+}
+
+func TestMonitoringReconcilerExpandsSizing(t *testing.T) {
+	user := &monitoringv1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: "user-workload-monitoring-config"},
+		Spec:       monitoringv1.UserSpec{Sizing: monitoringv1.SizingSmall},
+	}
+	c := newFakeClient(t, user)
+	target := userTarget{}
+	reconcileTarget(t, c, target)
+
+	want := `prometheus:
+  resources:
+    limits:
+      memory: 4Gi
+    requests:
+      cpu: 200m
+      memory: 2Gi
+  retention: 7d
+  volumeClaimTemplate:
+    spec:
+      resources:
+        requests:
+          storage: 20Gi
+thanosRuler:
+  resources:
+    limits:
+      memory: 512Mi
+    requests:
+      cpu: 10m
+      memory: 128Mi
+  retention: 24h
+  volumeClaimTemplate:
+    spec:
+      resources:
+        requests:
+          storage: 10Gi
+`
+	if got := renderedConfig(t, c, target); got != want {
+		t.Errorf("config.yaml = %q, want %q", got, want)
+	}
+
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(user), user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Spec.Prometheus.Resources != nil {
+		t.Errorf("spec.prometheus.resources = %+v, want the preset kept out of the spec", user.Spec.Prometheus.Resources)
+	}
+	if len(user.Status.Sizing) != 2 || user.Status.Sizing[0].Component != "prometheus" || user.Status.Sizing[0].Retention != "7d" {
+		t.Errorf("status.sizing = %+v, want prometheus and thanosRuler", user.Status.Sizing)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+const (
+	// snapshotPollInterval is how often a pending VolumeSnapshot is checked.
+	snapshotPollInterval = 10 * time.Second
+	// snapshotTimeout is how long a VolumeSnapshot may take to become ready
+	// before the expansion waiting on it is reported as failed.
+	snapshotTimeout = 30 * time.Minute
+
+	snapshotComponentLabel = "monitoring.arthurvardevanyan.com/component"
+	snapshotPVCLabel       = "monitoring.arthurvardevanyan.com/pvc"
+)
+
+// The VolumeSnapshot CRD is optional in a cluster, so snapshots are handled as
+// unstructured objects rather than pulling in the external-snapshotter API.
+var volumeSnapshotGVK = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+
+// snapshotName names the snapshot taken before expanding pvc to size, so that
+// repeated reconciles of the same expansion reuse one snapshot.
+func snapshotName(pvc string, size resource.Quantity) string {
+	return strings.ToLower(fmt.Sprintf("%s-%s", pvc, size.String()))
+}
+
+// snapshotPVC ensures a VolumeSnapshot of the PVC exists before it is expanded
+// to size, and reports whether it is ready to use. A snapshot that reports an
+// error or is not ready within snapshotTimeout is returned as an error; it is
+// kept, so deleting it retries the snapshot.
+func snapshotPVC(ctx context.Context, c client.Client, component string, pvc *corev1.PersistentVolumeClaim, size resource.Quantity, snapshot *monitoringv1.SnapshotBeforeChange) (bool, error) {
+	log := log.FromContext(ctx)
+
+	volumeSnapshot := &unstructured.Unstructured{}
+	volumeSnapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	name := snapshotName(pvc.Name, size)
+	err := c.Get(ctx, client.ObjectKey{Namespace: pvc.Namespace, Name: name}, volumeSnapshot)
+	if apierrors.IsNotFound(err) {
+		log.Info("Creating VolumeSnapshot before expanding PVC", "pvc", pvc.Name, "snapshot", name)
+		volumeSnapshot.SetNamespace(pvc.Namespace)
+		volumeSnapshot.SetName(name)
+		volumeSnapshot.SetLabels(map[string]string{
+			snapshotComponentLabel: component,
+			snapshotPVCLabel:       pvc.Name,
+		})
+		spec := map[string]interface{}{
+			"source": map[string]interface{}{
+				"persistentVolumeClaimName": pvc.Name,
+			},
+		}
+		if snapshot.VolumeSnapshotClassName != "" {
+			spec["volumeSnapshotClassName"] = snapshot.VolumeSnapshotClassName
+		}
+		volumeSnapshot.Object["spec"] = spec
+		if err := c.Create(ctx, volumeSnapshot); err != nil {
+			return false, fmt.Errorf("unable to create VolumeSnapshot %s/%s: %w", pvc.Namespace, name, err)
+		}
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("unable to get VolumeSnapshot %s/%s: %w", pvc.Namespace, name, err)
+	}
+
+	if message, found, _ := unstructured.NestedString(volumeSnapshot.Object, "status", "error", "message"); found {
+		return false, fmt.Errorf("VolumeSnapshot %s/%s failed: %s", pvc.Namespace, name, message)
+	}
+	ready, _, _ := unstructured.NestedBool(volumeSnapshot.Object, "status", "readyToUse")
+	if created := volumeSnapshot.GetCreationTimestamp(); !ready && !created.IsZero() && time.Since(created.Time) > snapshotTimeout {
+		return false, fmt.Errorf("VolumeSnapshot %s/%s is not ready after %s", pvc.Namespace, name, snapshotTimeout)
+	}
+	return ready, nil
+}
+
+// pruneSnapshots deletes all but the newest retention snapshots of each PVC of
+// component and returns the ones that are kept.
+func pruneSnapshots(ctx context.Context, c client.Client, namespace string, component string, retention int32) ([]monitoringv1.PVCSnapshot, error) {
+	log := log.FromContext(ctx)
+
+	snapshotList := &unstructured.UnstructuredList{}
+	snapshotList.SetGroupVersionKind(volumeSnapshotGVK.GroupVersion().WithKind("VolumeSnapshotList"))
+	if err := c.List(ctx, snapshotList, client.InNamespace(namespace), client.MatchingLabels{snapshotComponentLabel: component}); err != nil {
+		return nil, fmt.Errorf("unable to list VolumeSnapshots in namespace %s: %w", namespace, err)
+	}
+
+	snapshots := snapshotList.Items
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		created, newer := snapshots[i].GetCreationTimestamp(), snapshots[j].GetCreationTimestamp()
+		return newer.Before(&created)
+	})
+
+	var kept []monitoringv1.PVCSnapshot
+	perPVC := make(map[string]int32)
+	for i := range snapshots {
+		volumeSnapshot := &snapshots[i]
+		pvc := volumeSnapshot.GetLabels()[snapshotPVCLabel]
+		perPVC[pvc]++
+		if perPVC[pvc] > retention {
+			log.Info("Pruning VolumeSnapshot", "pvc", pvc, "snapshot", volumeSnapshot.GetName())
+			if err := c.Delete(ctx, volumeSnapshot); client.IgnoreNotFound(err) != nil {
+				return kept, fmt.Errorf("unable to delete VolumeSnapshot %s/%s: %w", namespace, volumeSnapshot.GetName(), err)
+			}
+			continue
+		}
+		ready, _, _ := unstructured.NestedBool(volumeSnapshot.Object, "status", "readyToUse")
+		kept = append(kept, monitoringv1.PVCSnapshot{
+			Name:       volumeSnapshot.GetName(),
+			Component:  component,
+			PVC:        pvc,
+			ReadyToUse: ready,
+		})
+	}
+	return kept, nil
+}
+
+// setSnapshotStatus replaces the snapshots recorded for component.
+func setSnapshotStatus(statuses []monitoringv1.PVCSnapshot, component string, snapshots []monitoringv1.PVCSnapshot) []monitoringv1.PVCSnapshot {
+	result := make([]monitoringv1.PVCSnapshot, 0, len(statuses)+len(snapshots))
+	for _, status := range statuses {
+		if status.Component != component {
+			result = append(result, status)
+		}
+	}
+	result = append(result, snapshots...)
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+func TestSnapshotPVC(t *testing.T) {
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-monitoring", Name: "prometheus-k8s-db-prometheus-k8s-0"},
+	}
+	size := resource.MustParse("20Gi")
+	tests := []struct {
+		name    string
+		created time.Time
+		status  map[string]interface{}
+		ready   bool
+		err     string
+	}{
+		{
+			name:    "pending",
+			created: time.Now(),
+		},
+		{
+			name:    "ready",
+			created: time.Now().Add(-time.Hour),
+			status:  map[string]interface{}{"readyToUse": true},
+			ready:   true,
+		},
+		{
+			name:    "error",
+			created: time.Now(),
+			status:  map[string]interface{}{"readyToUse": false, "error": map[string]interface{}{"message": "driver failure"}},
+			err:     "failed: driver failure",
+		},
+		{
+			name:    "timed out",
+			created: time.Now().Add(-snapshotTimeout - time.Minute),
+			status:  map[string]interface{}{"readyToUse": false},
+			err:     "is not ready after 30m0s",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			volumeSnapshot := &unstructured.Unstructured{}
+			volumeSnapshot.SetGroupVersionKind(volumeSnapshotGVK)
+			volumeSnapshot.SetNamespace(pvc.Namespace)
+			volumeSnapshot.SetName(snapshotName(pvc.Name, size))
+			volumeSnapshot.SetCreationTimestamp(metav1.NewTime(test.created))
+			if test.status != nil {
+				volumeSnapshot.Object["status"] = test.status
+			}
+			c := newFakeClient(t, volumeSnapshot)
+
+			ready, err := snapshotPVC(context.Background(), c, "prometheus-k8s", pvc, size, &monitoringv1.SnapshotBeforeChange{Retention: 1})
+			if ready != test.ready {
+				t.Errorf("ready = %v, want %v", ready, test.ready)
+			}
+			if test.err == "" && err != nil {
+				t.Errorf("err = %v, want none", err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("err = %v, want it to contain %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestPruneSnapshots(t *testing.T) {
+	created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	volumeSnapshot := func(name, component, pvc string, age time.Duration, ready bool) client.Object {
+		object := &unstructured.Unstructured{}
+		object.SetGroupVersionKind(volumeSnapshotGVK)
+		object.SetNamespace("openshift-monitoring")
+		object.SetName(name)
+		object.SetLabels(map[string]string{snapshotComponentLabel: component, snapshotPVCLabel: pvc})
+		object.SetCreationTimestamp(metav1.NewTime(created.Add(-age)))
+		object.Object["status"] = map[string]interface{}{"readyToUse": ready}
+		return object
+	}
+	c := newFakeClient(t,
+		volumeSnapshot("db-0-10gi", "prometheus-k8s", "db-0", 3*time.Hour, true),
+		volumeSnapshot("db-0-20gi", "prometheus-k8s", "db-0", 2*time.Hour, true),
+		volumeSnapshot("db-0-30gi", "prometheus-k8s", "db-0", time.Hour, false),
+		volumeSnapshot("db-1-20gi", "prometheus-k8s", "db-1", 2*time.Hour, true),
+		// Another component's snapshots are left alone
+		volumeSnapshot("alertmanager-10gi", "alertmanager-main", "alertmanager", 5*time.Hour, true),
+		volumeSnapshot("alertmanager-20gi", "alertmanager-main", "alertmanager", 4*time.Hour, true),
+	)
+
+	kept, err := pruneSnapshots(context.Background(), c, "openshift-monitoring", "prometheus-k8s", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []monitoringv1.PVCSnapshot{
+		{Name: "db-0-30gi", Component: "prometheus-k8s", PVC: "db-0"},
+		{Name: "db-0-20gi", Component: "prometheus-k8s", PVC: "db-0", ReadyToUse: true},
+		{Name: "db-1-20gi", Component: "prometheus-k8s", PVC: "db-1", ReadyToUse: true},
+	}
+	if !reflect.DeepEqual(kept, want) {
+		t.Errorf("kept = %+v, want %+v", kept, want)
+	}
+
+	remaining := &unstructured.UnstructuredList{}
+	remaining.SetGroupVersionKind(volumeSnapshotGVK.GroupVersion().WithKind("VolumeSnapshotList"))
+	if err := c.List(context.Background(), remaining); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, item := range remaining.Items {
+		names = append(names, item.GetName())
+	}
+	sort.Strings(names)
+	if wantNames := []string{"alertmanager-10gi", "alertmanager-20gi", "db-0-20gi", "db-0-30gi", "db-1-20gi"}; !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("remaining snapshots = %v, want %v", names, wantNames)
+	}
+}
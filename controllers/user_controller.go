@@ -18,18 +18,21 @@ package controllers
 
 import (
 	"context"
-	"strings"
+	"slices"
 
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
-	"sigs.k8s.io/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
-	monitoringv1beta1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1beta1"
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
 )
 
 // UserReconciler reconciles a User object
@@ -41,165 +44,182 @@ type UserReconciler struct {
 //+kubebuilder:rbac:groups=monitoring.arthurvardevanyan.com,resources=users,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=monitoring.arthurvardevanyan.com,resources=users/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=monitoring.arthurvardevanyan.com,resources=users/finalizers,verbs=update
-
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the User object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
-//
-// For more details, check Reconcile and its Result here:
-// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.1/pkg/reconcile
+//+kubebuilder:rbac:groups=monitoring.arthurvardevanyan.com,resources=userfragments,verbs=get;list;watch
+//+kubebuilder:rbac:groups=monitoring.arthurvardevanyan.com,resources=userfragments/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=monitoring.arthurvardevanyan.com,resources=userworkloadrequests,verbs=get;list;watch
+//+kubebuilder:rbac:groups=monitoring.arthurvardevanyan.com,resources=userworkloadrequests/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// Reconcile renders the User CR, with its UserFragments merged in and the
+// accepted UserWorkloadRequests applied, into the user-workload-monitoring-config
+// ConfigMap in openshift-user-workload-monitoring. See
+// monitoringReconciler.Reconcile.
 func (r *UserReconciler) Reconcile(reconcilerContext context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := log.FromContext(reconcilerContext)
-	log.V(1).Info(req.Name)
-
-	// Incept Object
-	var monitoring monitoringv1beta1.User
-	if err := r.Get(reconcilerContext, req.NamespacedName, &monitoring); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			log.V(1).Info("Monitoring Config Not Found or No Longer Exists!")
-			return ctrl.Result{}, nil
-		} else {
-			log.Error(err, "Unable to fetch Monitoring Object")
-			return ctrl.Result{}, client.IgnoreNotFound(err)
-		}
-	}
+	return (&monitoringReconciler{Client: r.Client, target: userTarget{}}).Reconcile(reconcilerContext, req)
+}
 
-	const (
-		finalizer     string = "user.monitoring.arthurvardevanyan.com/finalizer"
-		namespace     string = "openshift-user-workload-monitoring"
-		configMapName string = "user-workload-monitoring-config"
-	)
+// userTarget renders the User CR into the user workload monitoring ConfigMap.
+type userTarget struct{}
 
-	if req.Name != configMapName {
-		log.V(1).Info("Invalid Object Name!")
-		r.Delete(reconcilerContext, &monitoring)
-		return ctrl.Result{}, nil
-	}
+func (userTarget) newObject() client.Object { return &monitoringv1.User{} }
+func (userTarget) finalizer() string        { return "user.monitoring.arthurvardevanyan.com/finalizer" }
+func (userTarget) namespace() string        { return "openshift-user-workload-monitoring" }
+func (userTarget) configMapName() string    { return "user-workload-monitoring-config" }
 
-	configMapData := make(map[string]string)
-	MonitoringYaml, err := yaml.Marshal(&monitoring.Spec)
-	if err != nil {
-		log.Error(err, "Unable to Marshal ConfigMap Struct to Yaml!")
-		return ctrl.Result{}, err
+func (userTarget) requests(ctx context.Context, c client.Reader, object client.Object) (appliedRequests, error) {
+	return applyUserWorkloadRequests(ctx, c, &object.(*monitoringv1.User).Spec)
+}
+
+// autoSize is a no-op, as only prometheusK8s is auto-sized.
+func (userTarget) autoSize(context.Context, client.Reader, client.Object) (*monitoringv1.AutoSizingStatus, error) {
+	return nil, nil
+}
+
+// sizing leaves out a disabled Alertmanager, so the preset does not add a
+// section for it.
+func (userTarget) sizing(object client.Object) (monitoringv1.Sizing, []sizedComponent) {
+	spec := &object.(*monitoringv1.User).Spec
+	components := []sizedComponent{
+		{path: "prometheus", kind: "prometheus", resources: &spec.Prometheus.Resources, vct: &spec.Prometheus.VolumeClaimTemplate, retention: &spec.Prometheus.Retention},
+		{path: "thanosRuler", kind: "thanosRuler", resources: &spec.ThanosRuler.Resources, vct: &spec.ThanosRuler.VolumeClaimTemplate, retention: &spec.ThanosRuler.Retention},
 	}
-	configMapData["config.yaml"] = string(MonitoringYaml)
+	if spec.Alertmanager.Enabled {
+		components = append(components, sizedComponent{path: "alertmanager", kind: "alertmanager", resources: &spec.Alertmanager.Resources, vct: &spec.Alertmanager.VolumeClaimTemplate})
+	}
+	return spec.Sizing, components
+}
 
-	// Parse the YAML string into a map
-	var parsedData map[string]interface{}
-	err = yaml.Unmarshal([]byte(configMapData["config.yaml"]), &parsedData)
-	if err != nil {
-		log.Error(err, "Unable to Unmarshal ConfigMap Yaml to Struct!")
-		return ctrl.Result{}, err
+func (userTarget) status(object client.Object) targetStatus {
+	monitoring := object.(*monitoringv1.User)
+	return targetStatus{
+		pvcStatus:           &monitoring.Status.PVCStatus,
+		snapshots:           &monitoring.Status.Snapshots,
+		conditions:          &monitoring.Status.Conditions,
+		fieldOwners:         &monitoring.Status.FieldOwners,
+		sizing:              &monitoring.Status.Sizing,
+		unresolvedVariables: &monitoring.Status.UnresolvedVariables,
 	}
+}
 
-	removeMetadata(parsedData)
+func (userTarget) spec(object client.Object) interface{} {
+	return &object.(*monitoringv1.User).Spec
+}
 
-	// Convert the modified map back to YAML
-	modifiedYaml, err := yaml.Marshal(parsedData)
-	if err != nil {
-		log.Error(err, "Unable to Marshal Modified ConfigMap Struct to Yaml!")
-		return ctrl.Result{}, err
-	}
-	configMapData["config.yaml"] = string(modifiedYaml)
-
-	var ownerRef = metav1.OwnerReference{
-		APIVersion:         monitoring.APIVersion,
-		Kind:               monitoring.Kind,
-		Name:               monitoring.Name,
-		UID:                monitoring.UID,
-		Controller:         BoolPointer(true),
-		BlockOwnerDeletion: BoolPointer(true),
+func (userTarget) fragments(ctx context.Context, c client.Reader) ([]fragment, error) {
+	var list monitoringv1.UserFragmentList
+	if err := c.List(ctx, &list); err != nil {
+		return nil, err
 	}
-	ownerReference := []metav1.OwnerReference{ownerRef}
-
-	configMap := corev1.ConfigMap{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       "ConfigMap",
-			APIVersion: "v1",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            configMapName,
-			Namespace:       namespace,
-			OwnerReferences: ownerReference,
-		},
-		Data: configMapData,
+	fragments := make([]fragment, 0, len(list.Items))
+	for i := range list.Items {
+		item := &list.Items[i]
+		fragments = append(fragments, fragment{
+			object:     item,
+			kind:       "UserFragment",
+			priority:   item.Spec.Priority,
+			components: item.Spec.Components,
+			config:     &item.Spec.Config,
+			conditions: &item.Status.Conditions,
+		})
 	}
+	return fragments, nil
+}
 
-	// Delete Logic, Create / Update Finalizers, and Garbage Collect LogSink on Object Deletion
-	// https://book.kubebuilder.io/reference/using-finalizers.html
-	if monitoring.ObjectMeta.DeletionTimestamp.IsZero() {
-		// The object is not being deleted, so if it does not have our finalizer,
-		// then lets add the finalizer and update the object. This is equivalent
-		// registering our finalizer.
-		if !controllerutil.ContainsFinalizer(&monitoring, finalizer) {
-			// https://sdk.operatorframework.io/docs/building-operators/golang/references/client/#patch
-			patch := client.MergeFrom(monitoring.DeepCopy())
-			controllerutil.AddFinalizer(&monitoring, finalizer)
-			// https://sdk.operatorframework.io/docs/building-operators/golang/references/client/#patch
-			if err := r.Patch(reconcilerContext, &monitoring, patch); err != nil {
-				return ctrl.Result{}, err
-			} else {
-				return ctrl.Result{}, nil
-			}
-		}
-	} else {
-		// The object is being deleted
-		if controllerutil.ContainsFinalizer(&monitoring, finalizer) {
-			// our finalizer is present, so lets handle any external dependency
-			log.V(1).Info("Deleting ConfigMap!")
-			err := r.Delete(reconcilerContext, &configMap)
-			if err != nil {
-				log.Error(err, "Unable to Delete ConfigMap!")
-				return ctrl.Result{}, err
-			}
-
-			// remove our finalizer from the list and update it.
-			patch := client.MergeFrom(monitoring.DeepCopy())
-			controllerutil.RemoveFinalizer(&monitoring, finalizer)
-			if err := r.Patch(reconcilerContext, &monitoring, patch); err != nil {
-				return ctrl.Result{}, err
-			}
-		}
-
-		// Stop reconciliation as the item is being deleted
-		return ctrl.Result{}, nil
+func (userTarget) components(object client.Object) []pvcComponent {
+	monitoring := object.(*monitoringv1.User)
+	return []pvcComponent{
+		{statefulSet: "prometheus-user-workload", vct: monitoring.Spec.Prometheus.VolumeClaimTemplate},
+		{statefulSet: "alertmanager-user-workload", vct: monitoring.Spec.Alertmanager.VolumeClaimTemplate},
+		{statefulSet: "thanos-ruler-user-workload", vct: monitoring.Spec.ThanosRuler.VolumeClaimTemplate},
 	}
+}
 
-	// https://medium.com/@aneeshputtur/kubernetes-operators-with-external-configmap-b972c9c36bbe
-	err = r.Create(reconcilerContext, &configMap)
+func (userTarget) snapshot(object client.Object) *monitoringv1.SnapshotBeforeChange {
+	return object.(*monitoringv1.User).Spec.SnapshotBeforeChange
+}
+
+func (t userTarget) validate(ctx context.Context, c client.Reader, object client.Object) (field.ErrorList, bool, error) {
+	monitoring := object.(*monitoringv1.User)
+	errs := validateUserSpec(&monitoring.Spec)
+	secretErrs, err := validateSecrets(ctx, c, field.NewPath("spec", "alertmanager", "secrets"), t.namespace(), monitoring.Spec.Alertmanager.Secrets)
 	if err != nil {
-		log.V(1).Info("Update ConfigMap")
-		r.Update(reconcilerContext, &configMap)
-	} else {
-		log.V(1).Info("Create ConfigMap")
+		return nil, false, err
 	}
-
-	// Reconcile PVC sizes to match volumeClaimTemplate
-	if monitoring.Spec.Prometheus.VolumeClaimTemplate != nil {
-		if err := reconcilePVCSize(reconcilerContext, r.Client, namespace, "prometheus-user-workload-db-prometheus-user-workload-", monitoring.Spec.Prometheus.VolumeClaimTemplate); err != nil {
-			log.Error(err, "Unable to reconcile Prometheus PVC sizes")
-		}
+	refs := alertmanagerConfigSecretRefs(field.NewPath("spec", "prometheus", "additionalAlertmanagerConfigs"), monitoring.Spec.Prometheus.AdditionalAlertManagerConfigs)
+	refs = append(refs, alertmanagerConfigSecretRefs(field.NewPath("spec", "thanosRuler", "additionalAlertmanagerConfigs"), monitoring.Spec.ThanosRuler.AdditionalAlertManagerConfigs)...)
+	keyErrs, err := validateSecretKeys(ctx, c, t.namespace(), refs)
+	if err != nil {
+		return nil, false, err
 	}
-	if monitoring.Spec.Alertmanager.VolumeClaimTemplate != nil {
-		if err := reconcilePVCSize(reconcilerContext, r.Client, namespace, "alertmanager-user-workload-db-alertmanager-user-workload-", monitoring.Spec.Alertmanager.VolumeClaimTemplate); err != nil {
-			log.Error(err, "Unable to reconcile Alertmanager PVC sizes")
-		}
+	secretErrs = append(secretErrs, keyErrs...)
+	return append(errs, secretErrs...), len(secretErrs) > 0, nil
+}
+
+// warnings records the missing namespacesWithoutLabelEnforcement in the status,
+// so render can leave them out rather than render them as no-ops.
+func (userTarget) warnings(ctx context.Context, c client.Reader, object client.Object) ([]string, error) {
+	monitoring := object.(*monitoringv1.User)
+
+	missing, err := missingNamespaces(ctx, c, monitoring.Spec.NamespacesWithoutLabelEnforcement)
+	if err != nil {
+		return nil, err
 	}
-	if monitoring.Spec.ThanosRuler.VolumeClaimTemplate != nil {
-		if err := reconcilePVCSize(reconcilerContext, r.Client, namespace, "thanos-ruler-user-workload-data-thanos-ruler-user-workload-", monitoring.Spec.ThanosRuler.VolumeClaimTemplate); err != nil {
-			log.Error(err, "Unable to reconcile ThanosRuler PVC sizes")
-		}
+	monitoring.Status.MissingNamespaces = missing
+
+	var clusterSpec *monitoringv1.ClusterSpec
+	var cluster monitoringv1.Cluster
+	if err := getMerged(ctx, c, clusterTarget{}, &cluster); err == nil {
+		clusterSpec = &cluster.Spec
+	} else if !apierrors.IsNotFound(err) {
+		log.FromContext(ctx).Error(err, "Unable to fetch Cluster Monitoring Object")
 	}
+	return userWarnings(&monitoring.Spec, clusterSpec, missing), nil
+}
 
-	return ctrl.Result{}, nil
+func (userTarget) render(_ context.Context, _ client.Reader, object client.Object, capacities map[string]*resource.Quantity) (interface{}, error) {
+	monitoring := object.(*monitoringv1.User)
+	spec := monitoring.Spec.DeepCopy()
+	if spec.Prometheus.RetentionSize == "" {
+		spec.Prometheus.RetentionSize = retentionSize(spec.Prometheus.RetentionSizePercent, capacities["prometheus-user-workload"], spec.Prometheus.VolumeClaimTemplate)
+	}
+	spec.Prometheus.RetentionSizePercent = nil
+	spec.NamespacesWithoutLabelEnforcement = slices.DeleteFunc(spec.NamespacesWithoutLabelEnforcement, func(name string) bool {
+		return slices.Contains(monitoring.Status.MissingNamespaces, name)
+	})
+	spec.SnapshotBeforeChange = nil
+	spec.RequestPolicy = nil
+	spec.DefaultsProfile = ""
+	spec.Sizing = ""
+	return spec, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *UserReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&monitoringv1beta1.User{}).
+		// Status updates, e.g. the PVC retry count, must not trigger a reconcile
+		For(&monitoringv1.User{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// The Cluster CR decides whether the User CR takes effect, see userWarnings
+		Watches(&monitoringv1.Cluster{}, enqueueTarget(userTarget{}),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&monitoringv1.ClusterFragment{}, enqueueTarget(userTarget{}),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// Fragments are merged into the User CR, see mergeFragments
+		Watches(&monitoringv1.UserFragment{}, enqueueTarget(userTarget{}),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// Tenant requests are applied to the User CR, see applyRequests
+		Watches(&monitoringv1.UserWorkloadRequest{}, enqueueTarget(userTarget{}),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// Only the existence of a Namespace matters, see missingNamespaces
+		Watches(&corev1.Namespace{}, enqueueTarget(userTarget{}),
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc:  func(event.UpdateEvent) bool { return false },
+				GenericFunc: func(event.GenericEvent) bool { return false },
+			})).
+		// A resized PVC changes the retentionSize derived from its capacity
+		Watches(&corev1.PersistentVolumeClaim{}, enqueueTarget(userTarget{}),
+			builder.WithPredicates(pvcCapacityChanged(userTarget{}))).
+		// Template variables are read from this ConfigMap, see clusterVariables
+		Watches(&corev1.ConfigMap{}, enqueueTarget(userTarget{}),
+			builder.WithPredicates(predicate.NewPredicateFuncs(isVariablesConfigMap))).
 		Complete(r)
 }
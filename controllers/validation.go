@@ -0,0 +1,370 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+// conditionValid reports whether the spec passed the checks the CRD schema
+// cannot express. An invalid spec is not rendered into the ConfigMap.
+const conditionValid = "Valid"
+
+// conditionWarning is set while parts of a valid spec will not take effect.
+const conditionWarning = "Warning"
+
+// openshift416 replaced prometheus-adapter with metrics-server.
+var openshift416 = version.MajorMinor(4, 16)
+
+// validateClusterSpec checks the Cluster spec beyond its OpenAPI schema.
+func validateClusterSpec(spec *monitoringv1.ClusterSpec) field.ErrorList {
+	var errs field.ErrorList
+
+	nodeExporter := field.NewPath("spec", "nodeExporter")
+	errs = append(errs, validateRegexps(nodeExporter.Child("collectors", "systemd", "units"), spec.NodeExporter.Collectors.Systemd.Units)...)
+	if spec.NodeExporter.IgnoredNetworkDevices != nil {
+		errs = append(errs, validateRegexps(nodeExporter.Child("ignoredNetworkDevices"), *spec.NodeExporter.IgnoredNetworkDevices)...)
+	}
+
+	prometheusK8s := field.NewPath("spec", "prometheusK8s")
+	errs = append(errs, validateInterval(prometheusK8s.Child("scrapeInterval"), spec.PrometheusK8S.ScrapeInterval)...)
+	errs = append(errs, validateAlertmanagerConfigs(prometheusK8s.Child("additionalAlertmanagerConfigs"), spec.PrometheusK8S.AdditionalAlertManagerConfigs)...)
+
+	return errs
+}
+
+// validateUserSpec checks the User spec beyond its OpenAPI schema.
+func validateUserSpec(spec *monitoringv1.UserSpec) field.ErrorList {
+	var errs field.ErrorList
+
+	prometheus := field.NewPath("spec", "prometheus")
+	errs = append(errs, validateInterval(prometheus.Child("scrapeInterval"), spec.Prometheus.ScrapeInterval)...)
+	errs = append(errs, validateInterval(prometheus.Child("evaluationInterval"), spec.Prometheus.EvaluationInterval)...)
+	errs = append(errs, validateAlertmanagerConfigs(prometheus.Child("additionalAlertmanagerConfigs"), spec.Prometheus.AdditionalAlertManagerConfigs)...)
+	for i, remoteWrite := range spec.Prometheus.RemoteWrite {
+		remoteWritePath := prometheus.Child("remoteWrite").Index(i)
+		if parsed, err := url.Parse(remoteWrite.URL); err != nil || parsed.Host == "" {
+			errs = append(errs, field.Invalid(remoteWritePath.Child("url"), remoteWrite.URL, "must be an absolute URL"))
+		}
+		for j, relabel := range remoteWrite.WriteRelabelConfigs {
+			if _, err := regexp.Compile(relabel.Regex); err != nil {
+				errs = append(errs, field.Invalid(remoteWritePath.Child("writeRelabelConfigs").Index(j).Child("regex"), relabel.Regex, err.Error()))
+			}
+		}
+	}
+
+	for i, namespace := range spec.NamespacesWithoutLabelEnforcement {
+		if msgs := validation.IsDNS1123Label(namespace); len(msgs) > 0 {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "namespacesWithoutLabelEnforcement").Index(i), namespace, strings.Join(msgs, ", ")))
+		}
+	}
+
+	thanosRuler := field.NewPath("spec", "thanosRuler")
+	errs = append(errs, validateAlertmanagerConfigs(thanosRuler.Child("additionalAlertmanagerConfigs"), spec.ThanosRuler.AdditionalAlertManagerConfigs)...)
+	if interval := spec.ThanosRuler.EvaluationInterval; interval != "" {
+		if parsed, err := model.ParseDuration(string(interval)); err != nil || parsed == 0 {
+			errs = append(errs, field.Invalid(thanosRuler.Child("evaluationInterval"), interval, "must be a positive duration"))
+		}
+	}
+
+	return errs
+}
+
+// CMO rejects scrape and evaluation intervals outside of this range.
+const (
+	minInterval = 5 * time.Second
+	maxInterval = 5 * time.Minute
+)
+
+// validateInterval checks that a set interval is within the range CMO accepts.
+// The format itself is checked by the CRD pattern.
+func validateInterval(path *field.Path, interval monitoringv1.Duration) field.ErrorList {
+	if interval == "" {
+		return nil
+	}
+	parsed, err := model.ParseDuration(string(interval))
+	if err != nil {
+		return field.ErrorList{field.Invalid(path, interval, err.Error())}
+	}
+	if time.Duration(parsed) < minInterval || time.Duration(parsed) > maxInterval {
+		return field.ErrorList{field.Invalid(path, interval, "must be between 5s and 5m")}
+	}
+	return nil
+}
+
+// validateRegexps checks that every pattern compiles. node-exporter is written
+// in Go, so the patterns use the same RE2 syntax as the regexp package.
+func validateRegexps(path *field.Path, patterns []string) field.ErrorList {
+	var errs field.ErrorList
+	for i, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, field.Invalid(path.Index(i), pattern, err.Error()))
+		}
+	}
+	return errs
+}
+
+// validateAlertmanagerConfigs checks the additional Alertmanagers beyond the
+// schema: static configs must be host:port, and a client certificate needs
+// its key.
+func validateAlertmanagerConfigs(path *field.Path, configs []monitoringv1.AdditionalAlertManagerConfigs) field.ErrorList {
+	var errs field.ErrorList
+	for i, config := range configs {
+		configPath := path.Index(i)
+		for j, staticConfig := range config.StaticConfigs {
+			if host, port, err := net.SplitHostPort(staticConfig); err == nil && host != "" {
+				if n, err := strconv.Atoi(port); err == nil && len(validation.IsValidPortNum(n)) == 0 {
+					continue
+				}
+			}
+			errs = append(errs, field.Invalid(configPath.Child("staticConfigs").Index(j), staticConfig, "must be host:port"))
+		}
+		if config.TLSConfig != nil && (config.TLSConfig.Cert == nil) != (config.TLSConfig.Key == nil) {
+			errs = append(errs, field.Invalid(configPath.Child("tlsConfig"), "", "cert and key must be set together"))
+		}
+	}
+	return errs
+}
+
+// secretPollInterval is how often a spec referencing a missing Secret is
+// checked again, as Secrets are not watched.
+const secretPollInterval = time.Minute
+
+// validateSecrets checks that the named Secrets exist in namespace. Secrets are
+// not cached by the manager, so each one is read from the API server.
+func validateSecrets(ctx context.Context, c client.Reader, path *field.Path, namespace string, names []string) (field.ErrorList, error) {
+	var errs field.ErrorList
+	for i, name := range names {
+		if msgs := validation.IsDNS1123Subdomain(name); len(msgs) > 0 {
+			errs = append(errs, field.Invalid(path.Index(i), name, strings.Join(msgs, ", ")))
+			continue
+		}
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				errs = append(errs, field.NotFound(path.Index(i), namespace+"/"+name))
+				continue
+			}
+			return errs, fmt.Errorf("unable to get Secret %s/%s: %w", namespace, name, err)
+		}
+	}
+	return errs, nil
+}
+
+// missingNamespaces returns the names of namespaces that do not exist. Unlike
+// Secrets, namespaces are watched, so a namespace created later is picked up.
+func missingNamespaces(ctx context.Context, c client.Reader, names []string) ([]string, error) {
+	var missing []string
+	for _, name := range names {
+		namespace := &corev1.Namespace{}
+		if err := c.Get(ctx, client.ObjectKey{Name: name}, namespace); err != nil {
+			if apierrors.IsNotFound(err) {
+				missing = append(missing, name)
+				continue
+			}
+			return nil, fmt.Errorf("unable to get Namespace %s: %w", name, err)
+		}
+	}
+	return missing, nil
+}
+
+// secretKeyRef is a reference to a key of a Secret at path in the spec.
+type secretKeyRef struct {
+	path     *field.Path
+	selector *corev1.SecretKeySelector
+}
+
+// alertmanagerConfigSecretRefs returns the Secret keys the additional
+// Alertmanagers reference.
+func alertmanagerConfigSecretRefs(path *field.Path, configs []monitoringv1.AdditionalAlertManagerConfigs) []secretKeyRef {
+	var refs []secretKeyRef
+	add := func(path *field.Path, selector *corev1.SecretKeySelector) {
+		if selector != nil {
+			refs = append(refs, secretKeyRef{path: path, selector: selector})
+		}
+	}
+	for i, config := range configs {
+		configPath := path.Index(i)
+		add(configPath.Child("bearerToken"), config.BearerToken)
+		if config.TLSConfig != nil {
+			tlsPath := configPath.Child("tlsConfig")
+			add(tlsPath.Child("ca"), config.TLSConfig.CA)
+			add(tlsPath.Child("cert"), config.TLSConfig.Cert)
+			add(tlsPath.Child("key"), config.TLSConfig.Key)
+		}
+	}
+	return refs
+}
+
+// validateSecretKeys checks that the referenced Secrets exist in namespace and
+// contain the referenced keys. Optional references may be missing.
+func validateSecretKeys(ctx context.Context, c client.Reader, namespace string, refs []secretKeyRef) (field.ErrorList, error) {
+	var errs field.ErrorList
+	for _, ref := range refs {
+		name, key := ref.selector.Name, ref.selector.Key
+		if name == "" {
+			errs = append(errs, field.Required(ref.path.Child("name"), ""))
+			continue
+		}
+		if key == "" {
+			errs = append(errs, field.Required(ref.path.Child("key"), ""))
+			continue
+		}
+		optional := ref.selector.Optional != nil && *ref.selector.Optional
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				if !optional {
+					errs = append(errs, field.NotFound(ref.path.Child("name"), namespace+"/"+name))
+				}
+				continue
+			}
+			return errs, fmt.Errorf("unable to get Secret %s/%s: %w", namespace, name, err)
+		}
+		if _, ok := secret.Data[key]; !ok && !optional {
+			errs = append(errs, field.NotFound(ref.path.Child("key"), key))
+		}
+	}
+	return errs, nil
+}
+
+// clusterWarnings lists the settings in the Cluster spec that CMO will ignore
+// on the given OpenShift version, that conflict with the User spec or that size
+// a component below the minimum this controller suggests. Version dependent
+// checks are skipped if the version is nil, and User dependent ones if there is
+// no User CR.
+func clusterWarnings(spec *monitoringv1.ClusterSpec, openshift *version.Version, userSpec *monitoringv1.UserSpec) []string {
+	var warnings []string
+
+	if spec.AlertmanagerMain.EnableUserAlertmanagerConfig {
+		if spec.AlertmanagerMain.Enabled != nil && !*spec.AlertmanagerMain.Enabled {
+			warnings = append(warnings, "alertmanagerMain.enableUserAlertmanagerConfig has no effect while alertmanagerMain is disabled")
+		}
+		if userSpec != nil && userSpec.Alertmanager.Enabled {
+			warnings = append(warnings, "alertmanagerMain.enableUserAlertmanagerConfig conflicts with the user workload Alertmanager enabled in the User CR, which receives the user alerts instead")
+		}
+	}
+
+	warnings = append(warnings, resourcesBelow("thanosQuerier.resources", spec.ThanosQuerier.Resources, thanosQuerierMinimums)...)
+
+	if spec.TelemeterClient.Token != "" {
+		warnings = append(warnings, "telemeterClient.token is deprecated, move the token into a Secret in openshift-monitoring referenced by telemeterClient.tokenSecret")
+	}
+
+	if openshift != nil {
+		adapterSet := !equality.Semantic.DeepEqual(spec.K8sPrometheusAdapter, monitoringv1.K8sPrometheusAdapter{})
+		metricsServerSet := !equality.Semantic.DeepEqual(spec.MetricsServer, monitoringv1.MetricsServer{})
+		if adapterSet && openshift.AtLeast(openshift416) {
+			warnings = append(warnings, "k8sPrometheusAdapter is ignored on OpenShift "+openshift.String()+", metrics-server serves the resource metrics API from 4.16 on")
+		}
+		if metricsServerSet && !openshift.AtLeast(openshift416) {
+			warnings = append(warnings, "metricsServer is ignored on OpenShift "+openshift.String()+" unless the MetricsServer feature gate is enabled")
+		}
+		userWorkloadSet := !equality.Semantic.DeepEqual(spec.UserWorkload, monitoringv1.UserWorkload{})
+		if userWorkloadSet && !openshift.AtLeast(openshift416) {
+			warnings = append(warnings, "userWorkload is ignored on OpenShift "+openshift.String()+", it requires 4.16 or later")
+		}
+	}
+
+	return warnings
+}
+
+// thanosQuerierMinimums are the resources below which Thanos Querier is
+// flagged. They are a heuristic of this controller to catch typos such as 10Ki
+// for 10Mi, not a minimum CMO documents or enforces.
+var thanosQuerierMinimums = corev1.ResourceList{
+	corev1.ResourceCPU:    resource.MustParse("10m"),
+	corev1.ResourceMemory: resource.MustParse("12Mi"),
+}
+
+// resourcesBelow lists the requests and limits in resources that are set below
+// minimums.
+func resourcesBelow(path string, resources *corev1.ResourceRequirements, minimums corev1.ResourceList) []string {
+	if resources == nil {
+		return nil
+	}
+	var warnings []string
+	check := func(kind string, list corev1.ResourceList) {
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			minimum, ok := minimums[name]
+			if quantity, set := list[name]; ok && set && quantity.Cmp(minimum) < 0 {
+				warnings = append(warnings, fmt.Sprintf("%s.%s.%s %s is below the suggested minimum of %s", path, kind, name, quantity.String(), minimum.String()))
+			}
+		}
+	}
+	check("requests", resources.Requests)
+	check("limits", resources.Limits)
+	return warnings
+}
+
+// userWarnings lists the settings in the User spec that will not take effect
+// because of the Cluster spec or because the namespaces they name are missing.
+// Cluster dependent checks are skipped if there is no Cluster CR.
+func userWarnings(spec *monitoringv1.UserSpec, clusterSpec *monitoringv1.ClusterSpec, missing []string) []string {
+	var warnings []string
+	if len(missing) > 0 {
+		warnings = append(warnings, "namespacesWithoutLabelEnforcement are left out until they exist: "+strings.Join(missing, ", "))
+	}
+
+	if clusterSpec == nil {
+		return warnings
+	}
+	if !clusterSpec.EnableUserWorkload {
+		return append(warnings, "enableUserWorkload is not set in the Cluster CR, so user workload monitoring is not deployed and none of the User settings take effect")
+	}
+	if spec.Alertmanager.Enabled && clusterSpec.AlertmanagerMain.EnableUserAlertmanagerConfig {
+		warnings = append(warnings, "alertmanager.enabled conflicts with alertmanagerMain.enableUserAlertmanagerConfig in the Cluster CR; user alerts are sent to the user workload Alertmanager")
+	}
+	return warnings
+}
+
+// setWarningCondition reports warnings in the Warning condition, and removes
+// the condition when there are none.
+func setWarningCondition(conditions *[]metav1.Condition, generation int64, warnings []string) {
+	if len(warnings) == 0 {
+		meta.RemoveStatusCondition(conditions, conditionWarning)
+		return
+	}
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               conditionWarning,
+		Status:             metav1.ConditionTrue,
+		Reason:             "IgnoredSettings",
+		Message:            strings.Join(warnings, "; "),
+		ObservedGeneration: generation,
+	})
+}
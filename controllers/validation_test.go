@@ -0,0 +1,259 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/version"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+// errorFields returns the fields of errs, to compare them with the expected ones.
+func errorFields(errs field.ErrorList) []string {
+	var fields []string
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+	return fields
+}
+
+func TestValidateAlertmanagerConfigs(t *testing.T) {
+	secretKey := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "alertmanager-tls"}, Key: "tls.crt"}
+	tests := []struct {
+		name    string
+		configs []monitoringv1.AdditionalAlertManagerConfigs
+		want    []string
+	}{
+		{
+			name: "valid",
+			configs: []monitoringv1.AdditionalAlertManagerConfigs{{
+				StaticConfigs: []string{"alertmanager.example.com:9093", "10.0.0.1:9093", "[fd00::1]:9093"},
+				TLSConfig:     &monitoringv1.TLSConfig{Cert: secretKey, Key: secretKey},
+			}},
+		},
+		{
+			name: "static configs",
+			configs: []monitoringv1.AdditionalAlertManagerConfigs{
+				{StaticConfigs: []string{"alertmanager.example.com:9093"}},
+				{StaticConfigs: []string{"alertmanager.example.com", ":9093", "alertmanager.example.com:0", "alertmanager.example.com:65536", "fd00::1:9093"}},
+			},
+			want: []string{
+				"spec.prometheus.additionalAlertmanagerConfigs[1].staticConfigs[0]",
+				"spec.prometheus.additionalAlertmanagerConfigs[1].staticConfigs[1]",
+				"spec.prometheus.additionalAlertmanagerConfigs[1].staticConfigs[2]",
+				"spec.prometheus.additionalAlertmanagerConfigs[1].staticConfigs[3]",
+				"spec.prometheus.additionalAlertmanagerConfigs[1].staticConfigs[4]",
+			},
+		},
+		{
+			name: "cert without key",
+			configs: []monitoringv1.AdditionalAlertManagerConfigs{
+				{TLSConfig: &monitoringv1.TLSConfig{Cert: secretKey}},
+				{TLSConfig: &monitoringv1.TLSConfig{Key: secretKey}},
+				{TLSConfig: &monitoringv1.TLSConfig{CA: secretKey}},
+			},
+			want: []string{
+				"spec.prometheus.additionalAlertmanagerConfigs[0].tlsConfig",
+				"spec.prometheus.additionalAlertmanagerConfigs[1].tlsConfig",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateAlertmanagerConfigs(field.NewPath("spec", "prometheus", "additionalAlertmanagerConfigs"), tt.configs)
+			if got := errorFields(errs); !equality.Semantic.DeepEqual(got, tt.want) {
+				t.Errorf("errs = %v, want %v", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateUserSpecThanosRulerEvaluationInterval(t *testing.T) {
+	tests := []struct {
+		interval monitoringv1.Duration
+		valid    bool
+	}{
+		{interval: "", valid: true},
+		{interval: "30s", valid: true},
+		// Unlike Prometheus, Thanos Ruler is not limited to 5m
+		{interval: "15m", valid: true},
+		{interval: "1h30m", valid: true},
+		{interval: "0"},
+		{interval: "0s"},
+		{interval: "banana"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.interval), func(t *testing.T) {
+			spec := &monitoringv1.UserSpec{ThanosRuler: monitoringv1.ThanosRuler{EvaluationInterval: tt.interval}}
+			errs := validateUserSpec(spec)
+			if tt.valid && len(errs) != 0 {
+				t.Errorf("errs = %v, want none", errs)
+			}
+			if want := []string{"spec.thanosRuler.evaluationInterval"}; !tt.valid && !equality.Semantic.DeepEqual(errorFields(errs), want) {
+				t.Errorf("errs = %v, want %v", errs, want)
+			}
+		})
+	}
+}
+
+func TestClusterWarnings(t *testing.T) {
+	openshift415, openshift416 := version.MajorMinor(4, 15), version.MajorMinor(4, 16)
+	adapter := monitoringv1.K8sPrometheusAdapter{Audit: &monitoringv1.Audit{Profile: "Metadata"}}
+	metricsServer := monitoringv1.MetricsServer{Audit: &monitoringv1.Audit{Profile: "Metadata"}}
+	userWorkload := monitoringv1.UserWorkload{RulesWithoutLabelEnforcementAllowed: BoolPointer(false)}
+	tests := []struct {
+		name      string
+		spec      *monitoringv1.ClusterSpec
+		openshift *version.Version
+		userSpec  *monitoringv1.UserSpec
+		want      []string
+	}{
+		{
+			name: "none",
+			spec: &monitoringv1.ClusterSpec{
+				AlertmanagerMain:     monitoringv1.AlertmanagerMain{EnableUserAlertmanagerConfig: true},
+				K8sPrometheusAdapter: adapter,
+			},
+			openshift: openshift415,
+			userSpec:  &monitoringv1.UserSpec{},
+		},
+		{
+			name: "disabled Alertmanager",
+			spec: &monitoringv1.ClusterSpec{
+				AlertmanagerMain: monitoringv1.AlertmanagerMain{Enabled: BoolPointer(false), EnableUserAlertmanagerConfig: true},
+			},
+			want: []string{"alertmanagerMain.enableUserAlertmanagerConfig has no effect while alertmanagerMain is disabled"},
+		},
+		{
+			name: "user Alertmanager conflict",
+			spec: &monitoringv1.ClusterSpec{
+				AlertmanagerMain: monitoringv1.AlertmanagerMain{EnableUserAlertmanagerConfig: true},
+			},
+			userSpec: &monitoringv1.UserSpec{Alertmanager: monitoringv1.Alertmanager{Enabled: true}},
+			want:     []string{"alertmanagerMain.enableUserAlertmanagerConfig conflicts with the user workload Alertmanager enabled in the User CR, which receives the user alerts instead"},
+		},
+		{
+			name:      "before 4.16",
+			spec:      &monitoringv1.ClusterSpec{K8sPrometheusAdapter: adapter, MetricsServer: metricsServer, UserWorkload: userWorkload},
+			openshift: openshift415,
+			want: []string{
+				"metricsServer is ignored on OpenShift 4.15 unless the MetricsServer feature gate is enabled",
+				"userWorkload is ignored on OpenShift 4.15, it requires 4.16 or later",
+			},
+		},
+		{
+			name:      "from 4.16 on",
+			spec:      &monitoringv1.ClusterSpec{K8sPrometheusAdapter: adapter, MetricsServer: metricsServer, UserWorkload: userWorkload},
+			openshift: openshift416,
+			want:      []string{"k8sPrometheusAdapter is ignored on OpenShift 4.16, metrics-server serves the resource metrics API from 4.16 on"},
+		},
+		{
+			name: "unknown version",
+			spec: &monitoringv1.ClusterSpec{K8sPrometheusAdapter: adapter, MetricsServer: metricsServer, UserWorkload: userWorkload},
+		},
+		{
+			name: "Thanos Querier resources",
+			spec: &monitoringv1.ClusterSpec{ThanosQuerier: monitoringv1.ThanosQuerier{
+				CommonPodSettings: monitoringv1.CommonPodSettings{
+					PodSettings: monitoringv1.PodSettings{
+						Resources: &corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("5m"), corev1.ResourceMemory: resource.MustParse("12Mi")},
+							Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("10Ki")},
+						},
+					},
+				},
+			}},
+			want: []string{
+				"thanosQuerier.resources.requests.cpu 5m is below the suggested minimum of 10m",
+				"thanosQuerier.resources.limits.memory 10Ki is below the suggested minimum of 12Mi",
+			},
+		},
+		{
+			name: "inline telemeter token",
+			spec: &monitoringv1.ClusterSpec{TelemeterClient: monitoringv1.TelemeterClient{Token: "secret-token"}},
+			want: []string{"telemeterClient.token is deprecated, move the token into a Secret in openshift-monitoring referenced by telemeterClient.tokenSecret"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clusterWarnings(tt.spec, tt.openshift, tt.userSpec); !equality.Semantic.DeepEqual(got, tt.want) {
+				t.Errorf("clusterWarnings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserWarnings(t *testing.T) {
+	alertmanager := &monitoringv1.UserSpec{Alertmanager: monitoringv1.Alertmanager{Enabled: true}}
+	tests := []struct {
+		name        string
+		spec        *monitoringv1.UserSpec
+		clusterSpec *monitoringv1.ClusterSpec
+		missing     []string
+		want        []string
+	}{
+		{
+			name:        "none",
+			spec:        alertmanager,
+			clusterSpec: &monitoringv1.ClusterSpec{EnableUserWorkload: true},
+		},
+		{
+			name:    "without a Cluster CR",
+			spec:    alertmanager,
+			missing: []string{"team-a", "team-b"},
+			want:    []string{"namespacesWithoutLabelEnforcement are left out until they exist: team-a, team-b"},
+		},
+		{
+			name: "user workload not enabled",
+			spec: alertmanager,
+			clusterSpec: &monitoringv1.ClusterSpec{
+				AlertmanagerMain: monitoringv1.AlertmanagerMain{EnableUserAlertmanagerConfig: true},
+			},
+			want: []string{"enableUserWorkload is not set in the Cluster CR, so user workload monitoring is not deployed and none of the User settings take effect"},
+		},
+		{
+			name: "user Alertmanager conflict",
+			spec: alertmanager,
+			clusterSpec: &monitoringv1.ClusterSpec{
+				EnableUserWorkload: true,
+				AlertmanagerMain:   monitoringv1.AlertmanagerMain{EnableUserAlertmanagerConfig: true},
+			},
+			want: []string{"alertmanager.enabled conflicts with alertmanagerMain.enableUserAlertmanagerConfig in the Cluster CR; user alerts are sent to the user workload Alertmanager"},
+		},
+		{
+			name: "user Alertmanager disabled",
+			spec: &monitoringv1.UserSpec{},
+			clusterSpec: &monitoringv1.ClusterSpec{
+				EnableUserWorkload: true,
+				AlertmanagerMain:   monitoringv1.AlertmanagerMain{EnableUserAlertmanagerConfig: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := userWarnings(tt.spec, tt.clusterSpec, tt.missing); !equality.Semantic.DeepEqual(got, tt.want) {
+				t.Errorf("userWarnings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
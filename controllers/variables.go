@@ -0,0 +1,236 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// variablesConfigMapName is the ConfigMap in openshift-monitoring whose
+	// keys are the variables.<key> template variables.
+	variablesConfigMapName = "monitoring-variables"
+	// variablesPollInterval is how often a spec using variables of the
+	// ClusterVersion or Infrastructure is rendered again. Unlike the variables
+	// ConfigMap, they are not watched, as their kinds only exist on OpenShift.
+	variablesPollInterval = 5 * time.Minute
+	// configMapVariablePrefix prefixes the variables read from the variables
+	// ConfigMap.
+	configMapVariablePrefix = "variables."
+)
+
+// variablePattern matches a {{ name }} template variable. ${1} is left alone,
+// as relabeling uses it for capture groups.
+var variablePattern = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// variableFields are the fields that may use template variables, with [*] for
+// any map key or list index. The variables ConfigMap can be edited by anyone
+// with access to openshift-monitoring, so they are kept out of fields such as
+// remote write URLs that decide where metrics are sent.
+var variableFields = sets.New(
+	"spec.prometheusK8s.externalLabels[*]",
+	"spec.prometheus.externalLabels[*]",
+)
+
+// subscriptPattern matches the map keys and list indexes of a field path.
+var subscriptPattern = regexp.MustCompile(`\[[^\]]*\]`)
+
+// variableSource is a cluster-scoped object template variables are read from,
+// by variable name and field path.
+type variableSource struct {
+	gvk    schema.GroupVersionKind
+	name   string
+	fields map[string][]string
+}
+
+var variableSources = []variableSource{
+	{
+		gvk:  clusterVersionGVK,
+		name: "version",
+		fields: map[string][]string{
+			"clusterVersion.version":   {"status", "desired", "version"},
+			"clusterVersion.channel":   {"spec", "channel"},
+			"clusterVersion.clusterID": {"spec", "clusterID"},
+		},
+	},
+	{
+		gvk:  infrastructureGVK,
+		name: "cluster",
+		fields: map[string][]string{
+			"infrastructure.name":         {"status", "infrastructureName"},
+			"infrastructure.platform":     {"status", "platformStatus", "type"},
+			"infrastructure.apiServerURL": {"status", "apiServerURL"},
+		},
+	},
+}
+
+// clusterVariables returns the template variables read from the ClusterVersion,
+// the Infrastructure and the variables ConfigMap. The variables of a missing
+// object or field are left out, so using them leaves them unresolved.
+func clusterVariables(ctx context.Context, c client.Reader) (map[string]string, error) {
+	variables := make(map[string]string)
+	for _, source := range variableSources {
+		object := &unstructured.Unstructured{}
+		object.SetGroupVersionKind(source.gvk)
+		if err := c.Get(ctx, client.ObjectKey{Name: source.name}, object); err != nil {
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("unable to get %s: %w", source.gvk.Kind, err)
+		}
+		for name, path := range source.fields {
+			if value, _, _ := unstructured.NestedString(object.Object, path...); value != "" {
+				variables[name] = value
+			}
+		}
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: clusterTarget{}.namespace(), Name: variablesConfigMapName}, configMap); err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("unable to get ConfigMap %s: %w", variablesConfigMapName, err)
+	}
+	for key, value := range configMap.Data {
+		variables[configMapVariablePrefix+key] = value
+	}
+	return variables, nil
+}
+
+// isVariablesConfigMap filters events down to the variables ConfigMap.
+func isVariablesConfigMap(object client.Object) bool {
+	return object.GetNamespace() == clusterTarget{}.namespace() && object.GetName() == variablesConfigMapName
+}
+
+// usedVariables returns the template variables the strings of spec, a pointer
+// to a spec, use.
+func usedVariables(spec interface{}) sets.Set[string] {
+	used := sets.New[string]()
+	walkStrings(reflect.ValueOf(spec), field.NewPath("spec"), func(_ *field.Path, s string) string {
+		for _, match := range variablePattern.FindAllStringSubmatch(s, -1) {
+			used.Insert(match[1])
+		}
+		return s
+	})
+	return used
+}
+
+// pollVariables reports whether used has variables that are not read from the
+// watched variables ConfigMap, so the spec has to be rendered again regularly.
+func pollVariables(used sets.Set[string]) bool {
+	for name := range used {
+		if !strings.HasPrefix(name, configMapVariablePrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandVariables replaces the template variables in the strings of spec, a
+// pointer to a spec, with their values. A string using a variable without a
+// value, or outside of variableFields, is left unchanged and returned as
+// invalid, rather than rendered with the variable in it. The unresolved
+// variables are returned sorted.
+func expandVariables(spec interface{}, variables map[string]string) ([]string, field.ErrorList) {
+	unresolved := sets.New[string]()
+	var errs field.ErrorList
+	walkStrings(reflect.ValueOf(spec), field.NewPath("spec"), func(path *field.Path, s string) string {
+		if !variablePattern.MatchString(s) {
+			return s
+		}
+		if !variableFields.Has(subscriptPattern.ReplaceAllString(path.String(), "[*]")) {
+			errs = append(errs, field.Forbidden(path, "template variables are only allowed in "+strings.Join(sets.List(variableFields), ", ")))
+			return s
+		}
+		missing := sets.New[string]()
+		expanded := variablePattern.ReplaceAllStringFunc(s, func(match string) string {
+			name := variablePattern.FindStringSubmatch(match)[1]
+			value, ok := variables[name]
+			if !ok {
+				missing.Insert(name)
+			}
+			return value
+		})
+		if missing.Len() > 0 {
+			unresolved = unresolved.Union(missing)
+			errs = append(errs, field.Invalid(path, s, "unresolved variables: "+strings.Join(sets.List(missing), ", ")))
+			return s
+		}
+		return expanded
+	})
+	return sets.List(unresolved), errs
+}
+
+// walkStrings sets each string in v to what fn returns for it, given its path
+// along the json tags. Types with their own JSON encoding, like
+// resource.Quantity, are not walked into.
+func walkStrings(v reflect.Value, path *field.Path, fn func(path *field.Path, s string) string) {
+	if v.Type().Implements(jsonMarshalerType) || reflect.PointerTo(v.Type()).Implements(jsonMarshalerType) {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			walkStrings(v.Elem(), path, fn)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			switch {
+			case name == "-":
+			case opts == "inline" || (name == "" && field.Anonymous):
+				walkStrings(v.Field(i), path, fn)
+			default:
+				if name == "" {
+					name = field.Name
+				}
+				walkStrings(v.Field(i), path.Child(name), fn)
+			}
+		}
+	case reflect.Map:
+		// Map values are not addressable, so each is walked as a copy
+		iter := v.MapRange()
+		for iter.Next() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(iter.Value())
+			walkStrings(value, path.Key(fmt.Sprint(iter.Key().Interface())), fn)
+			v.SetMapIndex(iter.Key(), value)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkStrings(v.Index(i), path.Index(i), fn)
+		}
+	case reflect.String:
+		v.SetString(fn(path, v.String()))
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
+)
+
+func TestExpandVariables(t *testing.T) {
+	replacement := "${1}"
+	spec := func(labels map[string]string, url string) *monitoringv1.UserSpec {
+		return &monitoringv1.UserSpec{
+			Prometheus: monitoringv1.Prometheus{
+				ExternalLabels: labels,
+				RemoteWrite: []monitoringv1.RemoteWriteSpec{{
+					URL:                 url,
+					WriteRelabelConfigs: []monitoringv1.RelabelConfig{{Replacement: &replacement}},
+				}},
+			},
+		}
+	}
+	variables := map[string]string{
+		"infrastructure.name": "prod-x7k2p",
+		"variables.region":    "eu-west-1",
+	}
+	tests := []struct {
+		name       string
+		spec       *monitoringv1.UserSpec
+		want       *monitoringv1.UserSpec
+		used       []string
+		unresolved []string
+		errs       []string
+	}{
+		{
+			// Relabeling capture groups are not variables
+			name: "resolved",
+			spec: spec(map[string]string{"cluster": "{{ infrastructure.name }}", "region": "{{variables.region}}"}, "https://example.com/api/v1/write"),
+			want: spec(map[string]string{"cluster": "prod-x7k2p", "region": "eu-west-1"}, "https://example.com/api/v1/write"),
+			used: []string{"infrastructure.name", "variables.region"},
+		},
+		{
+			// The unresolved label is kept as is rather than half expanded
+			name:       "unresolved",
+			spec:       spec(map[string]string{"region": "{{variables.region}}-{{ variables.zone }}"}, "https://example.com/api/v1/write"),
+			want:       spec(map[string]string{"region": "{{variables.region}}-{{ variables.zone }}"}, "https://example.com/api/v1/write"),
+			used:       []string{"variables.region", "variables.zone"},
+			unresolved: []string{"variables.zone"},
+			errs:       []string{"spec.prometheus.externalLabels[region]"},
+		},
+		{
+			// Where metrics are sent cannot depend on the variables
+			name: "outside of the allowed fields",
+			spec: spec(map[string]string{"region": "{{ variables.region }}"}, "https://{{ variables.region }}.example.com/api/v1/write"),
+			want: spec(map[string]string{"region": "eu-west-1"}, "https://{{ variables.region }}.example.com/api/v1/write"),
+			used: []string{"variables.region"},
+			errs: []string{"spec.prometheus.remoteWrite[0].url"},
+		},
+		{
+			name: "none",
+			spec: spec(map[string]string{"cluster": "prod"}, "https://example.com/api/v1/write"),
+			want: spec(map[string]string{"cluster": "prod"}, "https://example.com/api/v1/write"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if used := sets.List(usedVariables(tt.spec)); !equality.Semantic.DeepEqual(used, tt.used) {
+				t.Errorf("usedVariables = %v, want %v", used, tt.used)
+			}
+			unresolved, errs := expandVariables(tt.spec, variables)
+			if !equality.Semantic.DeepEqual(unresolved, tt.unresolved) {
+				t.Errorf("unresolved = %v, want %v", unresolved, tt.unresolved)
+			}
+			if !equality.Semantic.DeepEqual(errorFields(errs), tt.errs) {
+				t.Errorf("errs = %v, want %v", errs, tt.errs)
+			}
+			if !reflect.DeepEqual(tt.spec, tt.want) {
+				t.Errorf("spec = %+v, want %+v", tt.spec.Prometheus, tt.want.Prometheus)
+			}
+		})
+	}
+}
+
+func TestPollVariables(t *testing.T) {
+	tests := []struct {
+		used []string
+		want bool
+	}{
+		{},
+		// The variables ConfigMap is watched
+		{used: []string{"variables.env", "variables.region"}},
+		{used: []string{"variables.env", "clusterVersion.version"}, want: true},
+		{used: []string{"infrastructure.name"}, want: true},
+	}
+	for _, tt := range tests {
+		if got := pollVariables(sets.New(tt.used...)); got != tt.want {
+			t.Errorf("pollVariables(%v) = %t, want %t", tt.used, got, tt.want)
+		}
+	}
+}
+
+func TestMonitoringReconcilerExpandsVariables(t *testing.T) {
+	infrastructure := &unstructured.Unstructured{}
+	infrastructure.SetGroupVersionKind(infrastructureGVK)
+	infrastructure.SetName("cluster")
+	if err := unstructured.SetNestedField(infrastructure.Object, "prod-x7k2p", "status", "infrastructureName"); err != nil {
+		t.Fatal(err)
+	}
+	cluster := &monitoringv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-monitoring-config"},
+		Spec: monitoringv1.ClusterSpec{
+			PrometheusK8S: monitoringv1.PrometheusK8S{
+				ExternalLabels: map[string]string{
+					"cluster": "{{ infrastructure.name }}",
+					"env":     "{{ variables.env }}",
+				},
+			},
+		},
+	}
+	c := newFakeClient(t, cluster, infrastructure)
+	target := clusterTarget{}
+
+	// Without the variables ConfigMap the ConfigMap is not rendered
+	if result := reconcileTarget(t, c, target); result.RequeueAfter != secretPollInterval {
+		t.Errorf("requeueAfter = %s, want %s", result.RequeueAfter, secretPollInterval)
+	}
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(cluster), cluster); err != nil {
+		t.Fatal(err)
+	}
+	if got := cluster.Status.UnresolvedVariables; !reflect.DeepEqual(got, []string{"variables.env"}) {
+		t.Errorf("status.unresolvedVariables = %v, want variables.env", got)
+	}
+	if meta.IsStatusConditionTrue(cluster.Status.Conditions, conditionValid) {
+		t.Error("Valid condition is true with an unresolved variable")
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: target.namespace(), Name: target.configMapName()}, configMap); err == nil {
+		t.Errorf("config.yaml = %q, want none rendered", configMap.Data["config.yaml"])
+	}
+
+	variables := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: target.namespace(), Name: variablesConfigMapName},
+		Data:       map[string]string{"env": "prod"},
+	}
+	if err := c.Create(context.Background(), variables); err != nil {
+		t.Fatal(err)
+	}
+	if result := reconcileTarget(t, c, target); result.RequeueAfter != variablesPollInterval {
+		t.Errorf("requeueAfter = %s, want %s", result.RequeueAfter, variablesPollInterval)
+	}
+	want := `prometheusK8s:
+  externalLabels:
+    cluster: prod-x7k2p
+    env: prod
+`
+	if got := renderedConfig(t, c, target); got != want {
+		t.Errorf("config.yaml = %q, want %q", got, want)
+	}
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(cluster), cluster); err != nil {
+		t.Fatal(err)
+	}
+	if cluster.Status.UnresolvedVariables != nil {
+		t.Errorf("status.unresolvedVariables = %v, want none", cluster.Status.UnresolvedVariables)
+	}
+	if cluster.Spec.PrometheusK8S.ExternalLabels["cluster"] != "{{ infrastructure.name }}" {
+		t.Errorf("spec.prometheusK8s.externalLabels = %v, want the variables kept in the spec", cluster.Spec.PrometheusK8S.ExternalLabels)
+	}
+}
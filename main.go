@@ -24,7 +24,9 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -36,6 +38,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	monitoringv1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1"
 	monitoringv1beta1 "github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/api/v1beta1"
 	"github.com/ArthurVardevanyan/openshift-monitoring-cr-controller/controllers"
 	//+kubebuilder:scaffold:imports
@@ -50,6 +53,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(monitoringv1beta1.AddToScheme(scheme))
+	utilruntime.Must(monitoringv1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -70,6 +74,13 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	// Monitoring PVCs and StatefulSets only live in these namespaces, so avoid
+	// caching them cluster-wide.
+	monitoringNamespaces := map[string]cache.Config{
+		"openshift-monitoring":               {},
+		"openshift-user-workload-monitoring": {},
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
 		Metrics: server.Options{
@@ -78,13 +89,30 @@ func main() {
 		WebhookServer: webhook.NewServer(webhook.Options{
 			Port: 9443,
 		}),
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				// Secrets referenced by the CRs are only checked for existence,
+				// so read them directly instead of caching every Secret. Nodes
+				// and Pods are only counted to auto-size Prometheus. ConfigMaps
+				// are read directly too, as only the variables ConfigMap is
+				// cached, see below.
+				DisableFor: []client.Object{&corev1.Secret{}, &corev1.Node{}, &corev1.Pod{}, &corev1.ConfigMap{}},
+			},
+		},
 		Cache: cache.Options{
 			ByObject: map[client.Object]cache.ByObject{
 				&corev1.PersistentVolumeClaim{}: {
-					Namespaces: map[string]cache.Config{
-						"openshift-monitoring":               {},
-						"openshift-user-workload-monitoring": {},
-					},
+					Namespaces: monitoringNamespaces,
+				},
+				&appsv1.StatefulSet{}: {
+					Namespaces: monitoringNamespaces,
+				},
+				// The variables ConfigMap is watched to expand the template
+				// variables again. The ConfigMap RBAC is scoped to its name,
+				// which only lets it be listed and watched by that name.
+				&corev1.ConfigMap{}: {
+					Namespaces: map[string]cache.Config{"openshift-monitoring": {}},
+					Field:      fields.OneTermEqualSelector("metadata.name", "monitoring-variables"),
 				},
 			},
 		},
@@ -122,6 +150,43 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "User")
 		os.Exit(1)
 	}
+	if err = (&controllers.PVCReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "PVC")
+		os.Exit(1)
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&monitoringv1.Cluster{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Cluster")
+			os.Exit(1)
+		}
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&monitoringv1.User{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "User")
+			os.Exit(1)
+		}
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&monitoringv1.ClusterFragment{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ClusterFragment")
+			os.Exit(1)
+		}
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&monitoringv1.UserFragment{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "UserFragment")
+			os.Exit(1)
+		}
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&monitoringv1.UserWorkloadRequest{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "UserWorkloadRequest")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {